@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"music-artwork-embedder/src/args"
 	"music-artwork-embedder/src/config"
 	"music-artwork-embedder/src/orchestrator"
+	"music-artwork-embedder/src/report"
+	"music-artwork-embedder/src/spotify"
+)
+
+// 終了コード
+const (
+	exitOK            = 0   // すべてのファイルを処理できた（スキップを含む）
+	exitFatal         = 1   // 引数・設定・初期化などのエラーで処理を続行できなかった
+	exitPartialFailed = 2   // 一部のファイルの処理に失敗した
+	exitInterrupted   = 130 // Ctrl-C等のシグナルで中断された
 )
 
 func main() {
@@ -17,61 +32,152 @@ func main() {
 		if err.Error() == "help requested" {
 			fmt.Println("使用法:")
 			fmt.Println("  音楽ファイル処理: go run main.go [オプション] <音楽ファイルまたはディレクトリパス>")
+			fmt.Println("  残ったファイルの復旧: go run main.go recover [-n] <音楽ファイルまたはディレクトリパス>")
 			fmt.Println("")
 			fmt.Println("オプション:")
 			fmt.Println("  -f, --force    既存のアートワークを強制的に上書きする")
+			fmt.Println("  -j, --jobs N   ディレクトリ内のファイルをN並列で処理する（デフォルト: 1）")
+			fmt.Println("  -n, --dry-run  ファイルを変更せず、各ファイルに対して行う予定の処理を表示する")
+			fmt.Println("  --resume       前回中断したディレクトリ処理を再開する（処理済みのファイルをスキップし、失敗したファイルのみ再試行）")
+			fmt.Println("  --verify-audio 埋め込み前後の音声データのハッシュを比較し、一致しない場合は元ファイルを置き換えない")
+			fmt.Println("  --no-preserve  元ファイルのパーミッション・所有者・アクセス/更新時刻を引き継がない（デフォルトは引き継ぐ）")
+			fmt.Println("  -o, --output-dir DIR  元ファイルを変更せず、入力のディレクトリ構成を再現してDIRに書き出す（スキップしたファイルもコピー）")
+			fmt.Println("  --filename-pattern P  タグのないファイルのパスからアーティスト等を取り出すパターン（複数指定可、指定順に試行）")
+			fmt.Println("                 {artist} {albumartist} {album} {title} {track} {disc} {year} が使用可能、/ で親フォルダも対象")
+			fmt.Println("  -v, --verbose  検索クエリ・APIのレスポンスなど、検索の経過を詳しく表示する")
+			fmt.Println("  --report PATH  ファイルごとの処理結果をPATHに書き出す（拡張子 .jsonl または .csv で形式を選択）")
 			fmt.Println("  -h, --help     このヘルプを表示する")
 			fmt.Println("")
 			fmt.Println("環境変数:")
 			fmt.Println("  SPOTIFY_CLIENT_ID     Spotify API Client ID")
 			fmt.Println("  SPOTIFY_CLIENT_SECRET Spotify API Client Secret")
+			fmt.Println("  SPOTIFY_REQUESTS_PER_SECOND  Spotify検索APIへの1秒あたりのリクエスト数の上限（デフォルト: 無制限）")
+			fmt.Println("  ARTWORK_PROVIDERS     試行するプロバイダーの順序（カンマ区切り、デフォルト: local,spotify,musicbrainz,itunes）")
+			fmt.Println("  MATCH_THRESHOLD       候補を採用する一致度の下限（0〜1、デフォルト: 0.6）")
+			fmt.Println("  SPOTIFY_TOKEN_URL         SpotifyトークンAPIのURL（省略時は公式サーバー）")
+			fmt.Println("  SPOTIFY_API_BASE_URL      Spotify Web APIのURL（省略時は公式サーバー）")
+			fmt.Println("  MUSICBRAINZ_BASE_URL      MusicBrainz APIのURL（省略時は公式サーバー）")
+			fmt.Println("  COVERARTARCHIVE_BASE_URL  Cover Art ArchiveのURL（省略時は公式サーバー）")
+			fmt.Println("  ITUNES_BASE_URL           iTunes Search APIのURL（省略時は公式サーバー）")
+			fmt.Println("  LOCAL_ARTWORK_PATTERNS    フォルダ内画像の追加パターン（カンマ区切り、例: *front*.jpg）")
+			fmt.Println("  FILENAME_PATTERNS         ファイル名パターン（カンマ区切り、--filename-pattern の後に試行）")
+			fmt.Println("  ITUNES_ARTWORK_SIZE       iTunesから取得する画像サイズ（1400 または 3000、デフォルト: 3000）")
+			fmt.Println("")
+			fmt.Println("終了コード:")
+			fmt.Println("  0  すべてのファイルを処理できた（スキップを含む）")
+			fmt.Println("  1  引数・設定・初期化などのエラーで処理を続行できなかった")
+			fmt.Println("  2  一部のファイルの処理に失敗した")
 			fmt.Println("")
 			fmt.Println("例:")
 			fmt.Println("  go run main.go music.mp3                    # 単一ファイルを処理")
 			fmt.Println("  go run main.go /path/to/music/directory     # ディレクトリを処理")
 			fmt.Println("  go run main.go -f music.mp3                 # 既存アートワークを強制上書き")
-			os.Exit(0)
+			fmt.Println("  go run main.go -j 8 /path/to/music/directory  # 8並列でディレクトリを処理")
+			fmt.Println("  go run main.go -n /path/to/music/directory  # 変更内容を事前に確認")
+			fmt.Println("  go run main.go --resume /path/to/music/directory  # 中断した処理を再開")
+			fmt.Println("  go run main.go -o /path/to/distribution /path/to/master  # 元ファイルを変更せずに配布用コピーを作成")
+			fmt.Println("  go run main.go --filename-pattern '{artist}/{album}/{track} {title}' /path/to/music  # タグのないファイルをフォルダ構成から検索")
+			fmt.Println("  go run main.go recover /path/to/music/directory  # 残った .backup / .tmp を検証して復旧")
+			fmt.Println("  go run main.go --report result.jsonl /path/to/music/directory  # 処理結果をJSON Linesで保存")
+			os.Exit(exitOK)
 		}
 		fmt.Println("エラー:", err)
 		fmt.Println("使用法: go run main.go [オプション] <音楽ファイルまたはディレクトリパス>")
 		fmt.Println("詳細は --help を参照してください")
-		os.Exit(1)
+		os.Exit(exitFatal)
 	}
 
 	// 設定を初期化
 	cfg := config.NewConfig(argsConfig.ForceOverwrite)
+	cfg.Jobs = argsConfig.Jobs
+	cfg.DryRun = argsConfig.DryRun
+	cfg.ReportPath = argsConfig.ReportPath
+	cfg.Resume = argsConfig.Resume
+	cfg.VerifyAudio = argsConfig.VerifyAudio
+	cfg.PreserveAttributes = !argsConfig.NoPreserve
+	cfg.OutputDir = argsConfig.OutputDir
+	cfg.FilenamePatterns = argsConfig.FilenamePatterns
+	cfg.Verbose = argsConfig.Verbose
 
 	// 環境変数を読み込み
 	if err := cfg.LoadEnv(); err != nil {
 		fmt.Printf("環境変数読み込みエラー: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitFatal)
 	}
 
-	// Spotify認証情報を検証
-	if err := cfg.ValidateSpotifyCredentials(); err != nil {
+	// recoverサブコマンドは残ったファイルの復旧のみ行う（プロバイダー・ffmpegは不要）
+	if argsConfig.Command == args.CommandRecover {
+		os.Exit(runRecover(cfg, inputPath))
+	}
+
+	// Spotify認証情報を検証（未設定の場合はSpotify以外のプロバイダーで続行）
+	if err := cfg.ValidateSpotifyCredentials(); err != nil && cfg.HasProvider(spotify.ProviderName) {
+		cfg.RemoveProvider(spotify.ProviderName)
+		if len(cfg.ArtworkProviders) == 0 {
+			fmt.Printf("エラー: %v\n", err)
+			os.Exit(exitFatal)
+		}
 		fmt.Printf("警告: %v\n", err)
-		os.Exit(1)
+		fmt.Printf("Spotifyを除いたプロバイダー (%s) で続行します\n", strings.Join(cfg.ArtworkProviders, ", "))
 	}
 
 	// ffmpegがインストールされているかチェック
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
 		fmt.Println("エラー: ffmpegがインストールされていません")
 		fmt.Println("ffmpegをインストールしてから再実行してください")
-		os.Exit(1)
+		os.Exit(exitFatal)
 	}
 
 	// ffprobeがインストールされているかチェック
 	if _, err := exec.LookPath("ffprobe"); err != nil {
 		fmt.Println("エラー: ffprobeがインストールされていません")
 		fmt.Println("ffprobe（ffmpegパッケージに含まれる）をインストールしてから再実行してください")
-		os.Exit(1)
+		os.Exit(exitFatal)
 	}
 
+	// 中断（Ctrl-C）されたら処理中のファイルを完了または元に戻してから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// done は正常に終了する際に stop() より先に閉じ、シグナルによる中断と区別する
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		select {
+		case <-done:
+			// 終了時の stop() によるキャンセル
+			return
+		default:
+		}
+		fmt.Println("\n中断しています。処理中のファイルを元に戻しています...（もう一度押すと強制終了）")
+		// 2回目のシグナルは通常どおりプロセスを終了させる
+		stop()
+	}()
+
 	// オーケストレーターを作成・初期化
 	orch := orchestrator.NewOrchestrator(cfg)
-	if err := orch.Initialize(); err != nil {
+	if err := orch.Initialize(ctx); err != nil {
+		orch.Close()
+		if ctx.Err() != nil {
+			fmt.Println("中断されました")
+			os.Exit(exitInterrupted)
+		}
 		fmt.Printf("初期化エラー: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitFatal)
+	}
+
+	// ドライランモードの表示
+	if cfg.DryRun {
+		fmt.Println("ドライランモード: ファイルは変更されません")
+	}
+
+	// 並列処理の表示
+	if cfg.Jobs > 1 {
+		fmt.Printf("並列処理: %d並列でファイルを処理します\n", cfg.Jobs)
 	}
 
 	// 強制上書きモードの表示
@@ -79,25 +185,126 @@ func main() {
 		fmt.Println("強制上書きモード: 既存のアートワークを置き換えます")
 	}
 
+	// 再開モードの表示
+	if cfg.Resume {
+		fmt.Println("再開モード: 前回までに処理済みのファイルをスキップします")
+	}
+
+	// 出力先ディレクトリの表示
+	if cfg.OutputDir != "" {
+		fmt.Printf("出力先: %s（元ファイルは変更しません）\n", cfg.OutputDir)
+	}
+
+	// 音声データ検証の表示
+	if cfg.VerifyAudio {
+		fmt.Println("音声データ検証: 埋め込み前後で音声データが一致する場合のみ置き換えます")
+	}
+
+	// レポート出力の表示
+	if cfg.ReportPath != "" {
+		fmt.Printf("レポート出力: %s\n", cfg.ReportPath)
+	}
+
 	// ファイルまたはディレクトリの処理
 	info, err := os.Stat(inputPath)
 	if err != nil {
+		orch.Close()
 		fmt.Printf("エラー: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitFatal)
 	}
 
 	if info.IsDir() {
 		fmt.Printf("ディレクトリを処理中: %s\n\n", inputPath)
-		if err := orch.ProcessDirectory(inputPath); err != nil {
+		if err := orch.ProcessDirectory(ctx, inputPath); err != nil && !errors.Is(err, ctx.Err()) {
+			orch.Close()
+			printSummary(orch.Summary(), cfg.DryRun)
 			fmt.Printf("ディレクトリ処理エラー: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitFatal)
 		}
 	} else {
-		if err := orch.ProcessFile(inputPath); err != nil {
+		// 失敗はサマリーに計上し、終了コードで通知する
+		// 計上される前のエラー（出力先の指定誤りや復旧の失敗など）は処理を続行できなかったものとして扱う
+		if err := orch.ProcessFile(ctx, inputPath); err != nil && !errors.Is(err, ctx.Err()) {
+			if orch.Summary().Failed == 0 {
+				orch.Close()
+				printSummary(orch.Summary(), cfg.DryRun)
+				fmt.Printf("ファイル処理エラー: %v\n", err)
+				os.Exit(exitFatal)
+			}
 			fmt.Printf("ファイル処理エラー: %v\n", err)
-			os.Exit(1)
 		}
 	}
 
+	if err := orch.Close(); err != nil {
+		fmt.Printf("警告: 終了処理でエラーが発生しました: %v\n", err)
+	}
+
+	summary := orch.Summary()
+	printSummary(summary, cfg.DryRun)
+
+	if ctx.Err() != nil {
+		fmt.Println("中断されました。未処理のファイルは --resume で再開できます")
+		os.Exit(exitInterrupted)
+	}
+
+	if summary.Failed > 0 {
+		fmt.Printf("%d件のファイルの処理に失敗しました\n", summary.Failed)
+		os.Exit(exitPartialFailed)
+	}
+	if cfg.DryRun {
+		fmt.Println("ドライランが完了しました（ファイルは変更されていません）")
+		return
+	}
 	fmt.Println("すべての処理が完了しました！")
 }
+
+// printSummary は処理結果の集計を表示
+func printSummary(summary report.Summary, dryRun bool) {
+	embedded, replaced := "埋め込み", "置き換え"
+	if dryRun {
+		embedded, replaced = "埋め込み予定", "置き換え予定"
+	}
+
+	fmt.Println("\n処理結果:")
+	fmt.Printf("  処理したファイル: %d\n", summary.Processed)
+	fmt.Printf("  %s: %d\n", embedded, summary.Embedded)
+	fmt.Printf("  %s: %d\n", replaced, summary.Replaced)
+	fmt.Printf("  スキップ: %d\n", summary.Skipped)
+	for _, r := range summary.SortedSkipReasons() {
+		fmt.Printf("    - %s: %d\n", r.Reason, r.Count)
+	}
+	fmt.Printf("  失敗: %d\n", summary.Failed)
+}
+
+// runRecover は残ったバックアップ・一時ファイルを検証して復旧し、終了コードを返す
+func runRecover(cfg *config.Config, inputPath string) int {
+	// ファイルの検証にffprobeを使用
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		fmt.Println("エラー: ffprobeがインストールされていません")
+		fmt.Println("ffprobe（ffmpegパッケージに含まれる）をインストールしてから再実行してください")
+		return exitFatal
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.DryRun {
+		fmt.Println("ドライランモード: ファイルは変更されません")
+	}
+
+	orch := orchestrator.NewOrchestrator(cfg)
+	failed, err := orch.Recover(ctx, inputPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("中断されました")
+			return exitInterrupted
+		}
+		fmt.Printf("復旧エラー: %v\n", err)
+		return exitFatal
+	}
+	if failed > 0 {
+		fmt.Printf("%d件のファイルを復旧できませんでした\n", failed)
+		return exitPartialFailed
+	}
+	return exitOK
+}
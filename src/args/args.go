@@ -3,17 +3,31 @@ package args
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
+// CommandRecover は残ったバックアップ・一時ファイルの復旧のみを行うサブコマンド
+const CommandRecover = "recover"
+
 // Config はアプリケーションの設定を管理
 type Config struct {
-	ForceOverwrite bool
+	Command          string // サブコマンド（通常の処理では空）
+	ForceOverwrite   bool
+	Jobs             int      // ディレクトリ処理の並列数
+	DryRun           bool     // ファイルを変更せず、実行予定の処理のみ表示する
+	ReportPath       string   // 処理結果のレポートを書き出すファイル（.jsonl または .csv）
+	Resume           bool     // 前回中断したディレクトリ処理を再開する
+	VerifyAudio      bool     // 埋め込み前後で音声データが変わっていないことをハッシュで検証する
+	NoPreserve       bool     // 元ファイルのパーミッション・所有者・時刻を引き継がない
+	OutputDir        string   // 埋め込み済みのファイルを書き出すディレクトリ（元ファイルは変更しない）
+	FilenamePatterns []string // タグのないファイルのパスに当てはめるパターン（指定順に試行）
+	Verbose          bool     // 検索の経過などの詳細を表示する
 }
 
 // ParseArgs はコマンドライン引数を解析
 func ParseArgs() (inputPath string, config *Config, err error) {
-	config = &Config{}
+	config = &Config{Jobs: 1}
 
 	if len(os.Args) < 2 {
 		return "", nil, fmt.Errorf("insufficient arguments")
@@ -22,10 +36,82 @@ func ParseArgs() (inputPath string, config *Config, err error) {
 	args := os.Args[1:]
 	var inputFound bool
 
-	for _, arg := range args {
-		switch arg {
+	if args[0] == CommandRecover {
+		config.Command = CommandRecover
+		args = args[1:]
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// "--jobs=4" 形式の値を分離
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "--") {
+			name, hasValue = arg, false
+		}
+
+		switch name {
 		case "--force", "-f":
 			config.ForceOverwrite = true
+		case "--dry-run", "-n":
+			config.DryRun = true
+		case "--jobs", "-j":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return "", nil, fmt.Errorf("%s には並列数を指定してください", name)
+				}
+				i++
+				value = args[i]
+			}
+			jobs, err := strconv.Atoi(value)
+			if err != nil || jobs < 1 {
+				return "", nil, fmt.Errorf("並列数は1以上の整数を指定してください: %s", value)
+			}
+			config.Jobs = jobs
+		case "--verbose", "-v":
+			config.Verbose = true
+		case "--resume":
+			config.Resume = true
+		case "--verify-audio":
+			config.VerifyAudio = true
+		case "--no-preserve":
+			config.NoPreserve = true
+		case "--output-dir", "-o":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return "", nil, fmt.Errorf("%s には出力先ディレクトリを指定してください", name)
+				}
+				i++
+				value = args[i]
+			}
+			if value == "" {
+				return "", nil, fmt.Errorf("出力先ディレクトリのパスが空です")
+			}
+			config.OutputDir = value
+		case "--filename-pattern":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return "", nil, fmt.Errorf("%s にはファイル名パターンを指定してください", name)
+				}
+				i++
+				value = args[i]
+			}
+			if value == "" {
+				return "", nil, fmt.Errorf("ファイル名パターンが空です")
+			}
+			config.FilenamePatterns = append(config.FilenamePatterns, value)
+		case "--report":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return "", nil, fmt.Errorf("%s にはレポートファイルのパスを指定してください", name)
+				}
+				i++
+				value = args[i]
+			}
+			if value == "" {
+				return "", nil, fmt.Errorf("レポートファイルのパスが空です")
+			}
+			config.ReportPath = value
 		case "--help", "-h":
 			return "", nil, fmt.Errorf("help requested")
 		default:
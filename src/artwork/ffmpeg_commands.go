@@ -1,13 +1,15 @@
 package artwork
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os/exec"
 )
 
 // EmbedArtworkMP3 はMP3ファイル専用の画像埋め込み
-func EmbedArtworkMP3(musicFile, artworkFile, outputFile string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkMP3(ctx context.Context, musicFile, artworkFile, outputFile string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:0", // 音声ストリーム
@@ -30,9 +32,9 @@ func EmbedArtworkMP3(musicFile, artworkFile, outputFile string) error {
 }
 
 // EmbedArtworkMP4 はMP4/M4Aファイル専用の画像埋め込み（音声ファイルのみ）
-func EmbedArtworkMP4(musicFile, artworkFile, outputFile string) error {
+func EmbedArtworkMP4(ctx context.Context, musicFile, artworkFile, outputFile string) error {
 	// まず標準的な方法を試行
-	cmd := exec.Command("ffmpeg",
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a", // 音声ストリーム
@@ -57,8 +59,8 @@ func EmbedArtworkMP4(musicFile, artworkFile, outputFile string) error {
 }
 
 // EmbedArtworkFLAC はFLACファイル専用の画像埋め込み
-func EmbedArtworkFLAC(musicFile, artworkFile, outputFile string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkFLAC(ctx context.Context, musicFile, artworkFile, outputFile string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a",
@@ -80,8 +82,8 @@ func EmbedArtworkFLAC(musicFile, artworkFile, outputFile string) error {
 }
 
 // EmbedArtworkGeneric は汎用の画像埋め込み
-func EmbedArtworkGeneric(musicFile, artworkFile, outputFile, format string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkGeneric(ctx context.Context, musicFile, artworkFile, outputFile, format string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a",
@@ -103,8 +105,8 @@ func EmbedArtworkGeneric(musicFile, artworkFile, outputFile, format string) erro
 }
 
 // EmbedArtworkForceReplaceMP3 はMP3ファイルの既存アートワークを強制置換
-func EmbedArtworkForceReplaceMP3(musicFile, artworkFile, outputFile string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkForceReplaceMP3(ctx context.Context, musicFile, artworkFile, outputFile string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a", // 音声ストリームのみ
@@ -127,8 +129,8 @@ func EmbedArtworkForceReplaceMP3(musicFile, artworkFile, outputFile string) erro
 }
 
 // EmbedArtworkForceReplaceMP4 はMP4/M4Aファイルの既存アートワークを強制置換
-func EmbedArtworkForceReplaceMP4(musicFile, artworkFile, outputFile string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkForceReplaceMP4(ctx context.Context, musicFile, artworkFile, outputFile string, out io.Writer) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a", // 音声ストリームのみ（既存画像を除外）
@@ -144,10 +146,10 @@ func EmbedArtworkForceReplaceMP4(musicFile, artworkFile, outputFile string) erro
 	)
 
 	output, err := cmd.CombinedOutput()
-	if err != nil {
+	if err != nil && ctx.Err() == nil {
 		// PNGで失敗した場合、JPEGで再試行
-		fmt.Printf("    PNG強制置換失敗、JPEGで再試行中...\n")
-		cmd = exec.Command("ffmpeg",
+		fmt.Fprintf(out, "    PNG強制置換失敗、JPEGで再試行中...\n")
+		cmd = exec.CommandContext(ctx, "ffmpeg",
 			"-i", musicFile,
 			"-i", artworkFile,
 			"-map", "0:a",
@@ -162,17 +164,17 @@ func EmbedArtworkForceReplaceMP4(musicFile, artworkFile, outputFile string) erro
 		)
 
 		output, err = cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("M4A強制置換エラー: %w\n出力: %s", err, string(output))
-		}
+	}
+	if err != nil {
+		return fmt.Errorf("M4A強制置換エラー: %w\n出力: %s", err, string(output))
 	}
 
 	return nil
 }
 
 // EmbedArtworkForceReplaceFLAC はFLACファイルの既存アートワークを強制置換
-func EmbedArtworkForceReplaceFLAC(musicFile, artworkFile, outputFile string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkForceReplaceFLAC(ctx context.Context, musicFile, artworkFile, outputFile string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a",
@@ -194,8 +196,8 @@ func EmbedArtworkForceReplaceFLAC(musicFile, artworkFile, outputFile string) err
 }
 
 // EmbedArtworkForceReplaceGeneric は汎用の既存アートワーク強制置換
-func EmbedArtworkForceReplaceGeneric(musicFile, artworkFile, outputFile, format string) error {
-	cmd := exec.Command("ffmpeg",
+func EmbedArtworkForceReplaceGeneric(ctx context.Context, musicFile, artworkFile, outputFile, format string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", musicFile,
 		"-i", artworkFile,
 		"-map", "0:a",
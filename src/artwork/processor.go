@@ -1,8 +1,12 @@
 package artwork
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"image"
+	_ "image/jpeg" // image.DecodeConfigでJPEGを扱うため
+	_ "image/png"  // image.DecodeConfigでPNGを扱うため
 	"io"
 	"net/http"
 	"os"
@@ -18,15 +22,21 @@ type Processor struct {
 }
 
 // NewProcessor は新しいアートワークプロセッサーを作成
-func NewProcessor() *Processor {
+// transport がnilの場合は http.DefaultTransport を使用
+func NewProcessor(transport http.RoundTripper) *Processor {
 	return &Processor{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
 	}
 }
 
 // DownloadImage は指定されたURLから画像をダウンロード
-func (p *Processor) DownloadImage(imageURL, outputPath string) error {
-	resp, err := p.httpClient.Get(imageURL)
+func (p *Processor) DownloadImage(ctx context.Context, imageURL, outputPath string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -46,9 +56,24 @@ func (p *Processor) DownloadImage(imageURL, outputPath string) error {
 	return err
 }
 
+// ImageSize は画像ファイルのヘッダーから幅と高さを取得（JPEG・PNGに対応）
+func ImageSize(imagePath string) (int, int, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
 // GetAudioFormat は音楽ファイルのフォーマットを取得
-func (p *Processor) GetAudioFormat(musicFile string) (string, error) {
-	cmd := exec.Command("ffprobe",
+func (p *Processor) GetAudioFormat(ctx context.Context, musicFile string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_format",
@@ -109,8 +134,8 @@ func (p *Processor) GetAudioFormat(musicFile string) (string, error) {
 }
 
 // HasExistingArtwork は音楽ファイルに既存のアートワークがあるかチェック
-func (p *Processor) HasExistingArtwork(musicFile string) (bool, error) {
-	cmd := exec.Command("ffprobe",
+func (p *Processor) HasExistingArtwork(ctx context.Context, musicFile string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_streams",
@@ -147,47 +172,47 @@ func (p *Processor) HasExistingArtwork(musicFile string) (bool, error) {
 }
 
 // EmbedArtwork はffmpegを使用してアートワークを埋め込み
-func (p *Processor) EmbedArtwork(musicFile, artworkFile, outputFile string) error {
+func (p *Processor) EmbedArtwork(ctx context.Context, musicFile, artworkFile, outputFile string, out io.Writer) error {
 	// 入力ファイルのフォーマットを取得
-	format, err := p.GetAudioFormat(musicFile)
+	format, err := p.GetAudioFormat(ctx, musicFile)
 	if err != nil {
 		return fmt.Errorf("フォーマット取得エラー: %w", err)
 	}
 
-	fmt.Printf("    検出されたフォーマット: %s\n", format)
+	fmt.Fprintf(out, "    検出されたフォーマット: %s\n", format)
 
 	// フォーマット別の処理
 	switch format {
 	case "mp3":
-		return EmbedArtworkMP3(musicFile, artworkFile, outputFile)
+		return EmbedArtworkMP3(ctx, musicFile, artworkFile, outputFile)
 	case "mp4":
-		return EmbedArtworkMP4(musicFile, artworkFile, outputFile)
+		return EmbedArtworkMP4(ctx, musicFile, artworkFile, outputFile)
 	case "flac":
-		return EmbedArtworkFLAC(musicFile, artworkFile, outputFile)
+		return EmbedArtworkFLAC(ctx, musicFile, artworkFile, outputFile)
 	default:
-		return EmbedArtworkGeneric(musicFile, artworkFile, outputFile, format)
+		return EmbedArtworkGeneric(ctx, musicFile, artworkFile, outputFile, format)
 	}
 }
 
 // EmbedArtworkForceReplace は既存アートワークを強制置換
-func (p *Processor) EmbedArtworkForceReplace(musicFile, artworkFile, outputFile string) error {
+func (p *Processor) EmbedArtworkForceReplace(ctx context.Context, musicFile, artworkFile, outputFile string, out io.Writer) error {
 	// 入力ファイルのフォーマットを取得
-	format, err := p.GetAudioFormat(musicFile)
+	format, err := p.GetAudioFormat(ctx, musicFile)
 	if err != nil {
 		return fmt.Errorf("フォーマット取得エラー: %w", err)
 	}
 
-	fmt.Printf("    検出されたフォーマット: %s\n", format)
+	fmt.Fprintf(out, "    検出されたフォーマット: %s\n", format)
 
 	// フォーマット別の処理
 	switch format {
 	case "mp3":
-		return EmbedArtworkForceReplaceMP3(musicFile, artworkFile, outputFile)
+		return EmbedArtworkForceReplaceMP3(ctx, musicFile, artworkFile, outputFile)
 	case "mp4":
-		return EmbedArtworkForceReplaceMP4(musicFile, artworkFile, outputFile)
+		return EmbedArtworkForceReplaceMP4(ctx, musicFile, artworkFile, outputFile, out)
 	case "flac":
-		return EmbedArtworkForceReplaceFLAC(musicFile, artworkFile, outputFile)
+		return EmbedArtworkForceReplaceFLAC(ctx, musicFile, artworkFile, outputFile)
 	default:
-		return EmbedArtworkForceReplaceGeneric(musicFile, artworkFile, outputFile, format)
+		return EmbedArtworkForceReplaceGeneric(ctx, musicFile, artworkFile, outputFile, format)
 	}
 }
\ No newline at end of file
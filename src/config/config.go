@@ -3,22 +3,62 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultArtworkProviders はアートワーク検索で試行するプロバイダーのデフォルト順序
+var DefaultArtworkProviders = []string{"local", "spotify", "musicbrainz", "itunes"}
+
+// DefaultMatchThreshold は候補を採用する一致度の下限のデフォルト値
+const DefaultMatchThreshold = 0.6
+
 // Config はアプリケーションの設定を管理
 type Config struct {
 	ForceOverwrite      bool
+	Jobs                int    // ディレクトリ処理の並列数（1は逐次処理）
+	DryRun              bool   // バックアップ・ダウンロード・埋め込みを行わず、予定のみ表示する
+	ReportPath          string // 処理結果のレポートを書き出すファイル（空なら出力しない）
+	Resume              bool   // ジャーナルを読み込み、前回までに処理済みのファイルをスキップする
+	VerifyAudio         bool   // 埋め込み前後で音声データのハッシュが一致しなければ置き換えない
+	PreserveAttributes  bool   // 元ファイルのパーミッション・所有者・アクセス/更新時刻を引き継ぐ
+	OutputDir           string // 元ファイルを変更せず、入力のツリーを再現して書き出すディレクトリ（空なら元ファイルを置き換える）
+	Verbose             bool   // プロバイダーの検索の経過（クエリ・レスポンスなど）を表示する
 	SpotifyClientID     string
 	SpotifyClientSecret string
+	SpotifyRateLimit    float64  // Spotify検索APIへの1秒あたりのリクエスト数の上限（0は無制限）
+	ArtworkProviders    []string // 試行順に並んだプロバイダー名
+	MatchThreshold      float64  // 候補を採用する一致度の下限（0〜1）
+
+	// 空の場合は各クライアントのデフォルトURLを使用
+	SpotifyTokenURL        string
+	SpotifyAPIBaseURL      string
+	MusicBrainzBaseURL     string
+	CoverArtArchiveBaseURL string
+	ITunesBaseURL          string
+
+	ITunesArtworkSize int // iTunesから取得する画像の一辺（1400 または 3000、0はデフォルト）
+
+	LocalArtworkPatterns []string // フォルダ内画像の追加パターン（デフォルトより先に試行）
+	FilenamePatterns     []string // タグのないファイルのパスからメタデータを取り出すパターン（試行順）
+
+	// HTTPTransport は全てのAPI通信・画像ダウンロードで使用するトランスポート
+	// nilの場合は http.DefaultTransport（テストではスタブサーバー向けのものを注入する）
+	HTTPTransport http.RoundTripper
 }
 
 // NewConfig は新しい設定インスタンスを作成
 func NewConfig(forceOverwrite bool) *Config {
 	return &Config{
-		ForceOverwrite: forceOverwrite,
+		ForceOverwrite:     forceOverwrite,
+		Jobs:               1,
+		PreserveAttributes: true,
+		ArtworkProviders:   DefaultArtworkProviders,
+		MatchThreshold:     DefaultMatchThreshold,
 	}
 }
 
@@ -38,6 +78,52 @@ func (c *Config) LoadEnv() error {
 	c.SpotifyClientID = os.Getenv("SPOTIFY_CLIENT_ID")
 	c.SpotifyClientSecret = os.Getenv("SPOTIFY_CLIENT_SECRET")
 
+	// 各APIのURL（ローカルのスタブサーバー等に向ける場合に指定）
+	c.SpotifyTokenURL = os.Getenv("SPOTIFY_TOKEN_URL")
+	c.SpotifyAPIBaseURL = os.Getenv("SPOTIFY_API_BASE_URL")
+	c.MusicBrainzBaseURL = os.Getenv("MUSICBRAINZ_BASE_URL")
+	c.CoverArtArchiveBaseURL = os.Getenv("COVERARTARCHIVE_BASE_URL")
+	c.ITunesBaseURL = os.Getenv("ITUNES_BASE_URL")
+
+	// Spotify検索APIのクライアント側レート制限
+	if value := os.Getenv("SPOTIFY_REQUESTS_PER_SECOND"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			return fmt.Errorf("SPOTIFY_REQUESTS_PER_SECOND は 0 以上の数値を指定してください: %s", value)
+		}
+		c.SpotifyRateLimit = rate
+	}
+
+	// 候補を採用する一致度の下限
+	if value := os.Getenv("MATCH_THRESHOLD"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return fmt.Errorf("MATCH_THRESHOLD は 0〜1 の数値を指定してください: %s", value)
+		}
+		c.MatchThreshold = threshold
+	}
+
+	// iTunesアートワークのサイズ
+	if value := os.Getenv("ITUNES_ARTWORK_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || (size != 1400 && size != 3000) {
+			return fmt.Errorf("ITUNES_ARTWORK_SIZE は 1400 または 3000 を指定してください: %s", value)
+		}
+		c.ITunesArtworkSize = size
+	}
+
+	// フォルダ内画像の追加パターン（例: "*front*.jpg,scan*.png"）
+	c.LocalArtworkPatterns = parseList(os.Getenv("LOCAL_ARTWORK_PATTERNS"))
+
+	// ファイル名パターン（例: "{track} - {artist} - {title},{artist}/{album}/{track} {title}"）
+	// コマンドラインで指定したパターンの後に試行する
+	c.FilenamePatterns = append(c.FilenamePatterns, parseList(os.Getenv("FILENAME_PATTERNS"))...)
+
+	// プロバイダーの試行順序を取得（例: "spotify,musicbrainz"）
+	if providers := parseList(strings.ToLower(os.Getenv("ARTWORK_PROVIDERS"))); len(providers) > 0 {
+		c.ArtworkProviders = providers
+	}
+
 	return nil
 }
 
@@ -48,3 +134,36 @@ func (c *Config) ValidateSpotifyCredentials() error {
 	}
 	return nil
 }
+
+// HasProvider は指定したプロバイダーが試行対象に含まれているかを返す
+func (c *Config) HasProvider(name string) bool {
+	for _, p := range c.ArtworkProviders {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveProvider は指定したプロバイダーを試行対象から除外
+func (c *Config) RemoveProvider(name string) {
+	var providers []string
+	for _, p := range c.ArtworkProviders {
+		if p != name {
+			providers = append(providers, p)
+		}
+	}
+	c.ArtworkProviders = providers
+}
+
+// parseList はカンマ区切りの文字列を空要素を除いたスライスに変換
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,18 @@
+//go:build linux || openbsd || dragonfly || solaris || illumos
+
+package fileutils
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime はファイルの最終アクセス時刻を返す（取得できなければ更新時刻）
+func accessTime(info os.FileInfo) time.Time {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime()
+	}
+	return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+}
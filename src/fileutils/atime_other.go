@@ -0,0 +1,13 @@
+//go:build !(linux || openbsd || dragonfly || solaris || illumos || darwin || freebsd || netbsd)
+
+package fileutils
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime は最終アクセス時刻を取得できないOSでは更新時刻を返す
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,17 @@
+package fileutils
+
+import (
+	"os"
+)
+
+// CopyAttributes は元ファイルのパーミッション・所有者・アクセス時刻・更新時刻を対象ファイルに反映する
+// 所有者は権限がある場合のみ変更し、変更できなくてもエラーにしない
+func CopyAttributes(info os.FileInfo, dst string) error {
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := copyOwner(info, dst); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, accessTime(info), info.ModTime())
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package fileutils
+
+import (
+	"os"
+)
+
+// copyOwner は所有者の概念が異なるOSでは何もしない
+func copyOwner(info os.FileInfo, dst string) error {
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package fileutils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// copyOwner は元ファイルの所有者・グループを対象ファイルに反映する（権限がなければ何もしない）
+func copyOwner(info os.FileInfo, dst string) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	err := os.Lchown(dst, int(stat.Uid), int(stat.Gid))
+	if errors.Is(err, os.ErrPermission) {
+		return nil
+	}
+	return err
+}
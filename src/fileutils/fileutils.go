@@ -1,16 +1,24 @@
 package fileutils
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // CreateBackup はファイルのバックアップを作成
 func CreateBackup(src, dst string) error {
+	return CopyFile(src, dst)
+}
+
+// CopyFile はファイルの内容をコピー
+func CopyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -33,13 +41,67 @@ func RestoreFromBackup(backupPath, originalPath string) error {
 		return fmt.Errorf("バックアップファイルが存在しません")
 	}
 
-	fmt.Printf("  エラー検出: バックアップから復元中...\n")
 	return os.Rename(backupPath, originalPath)
 }
 
 // ValidateAudioFile は音声ファイルの整合性をチェック
-func ValidateAudioFile(filePath string) error {
-	cmd := exec.Command("ffprobe",
+func ValidateAudioFile(ctx context.Context, filePath string) error {
+	output, err := probeDuration(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("ファイル検証失敗: %w", err)
+	}
+
+	// 出力が空でないことを確認
+	if len(output) == 0 {
+		return fmt.Errorf("ファイルが破損しています（duration取得不可）")
+	}
+
+	return nil
+}
+
+// AudioHash は音声ストリームのパケットをデコードせずにMD5ハッシュを計算する
+// タグやアートワークを変更しても、音声データが同じであれば同じ値になる
+func AudioHash(ctx context.Context, filePath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-i", filePath,
+		"-map", "0:a",
+		"-c", "copy",
+		"-f", "md5",
+		"-",
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("音声ハッシュ計算失敗: %w", err)
+	}
+
+	// 出力は "MD5=<hex>" の形式
+	hash, ok := strings.CutPrefix(strings.TrimSpace(string(output)), "MD5=")
+	if !ok || hash == "" {
+		return "", fmt.Errorf("音声ハッシュを解析できませんでした: %s", strings.TrimSpace(string(output)))
+	}
+	return hash, nil
+}
+
+// GetAudioDuration は音声ファイルの再生時間を取得
+func GetAudioDuration(ctx context.Context, filePath string) (time.Duration, error) {
+	output, err := probeDuration(ctx, filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(output, 64)
+	if err != nil {
+		return 0, fmt.Errorf("再生時間を解析できませんでした: %w", err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// probeDuration はffprobeで取得した再生時間（秒）の文字列を返す
+func probeDuration(ctx context.Context, filePath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "csv=p=0",
@@ -48,44 +110,68 @@ func ValidateAudioFile(filePath string) error {
 
 	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("ファイル検証失敗: %w", err)
+		return "", err
 	}
 
-	// 出力が空でないことを確認
-	if len(strings.TrimSpace(string(output))) == 0 {
-		return fmt.Errorf("ファイルが破損しています（duration取得不可）")
-	}
+	return strings.TrimSpace(string(output)), nil
+}
 
-	return nil
+// musicExtensions は処理対象とする音楽ファイルの拡張子
+var musicExtensions = map[string]bool{
+	".mp3":  true,
+	".m4a":  true,
+	".flac": true,
+	".wav":  true,
+}
+
+// IsMusicFile は処理対象の音楽ファイルかどうかを拡張子で判定
+func IsMusicFile(path string) bool {
+	return musicExtensions[strings.ToLower(filepath.Ext(path))]
 }
 
-// ProcessDirectory はディレクトリ内の音楽ファイルを再帰的に処理
-func ProcessDirectory(dirPath string, processFunc func(string) error) error {
-	musicExtensions := map[string]bool{
-		".mp3":  true,
-		".m4a":  true,
-		".flac": true,
-		".wav":  true,
+// WalkMusicDirs はディレクトリを再帰的に走査し、ディレクトリごとに直下の音楽ファイル（名前順）を fn に渡す
+// ディレクトリ内のファイルを渡してから、そのサブディレクトリを名前順に走査する（音楽ファイルのないディレクトリでは fn を呼ばない）
+// dirPath より下の読み取れないディレクトリは onError に渡し、残りの走査を続ける
+// ctx がキャンセルされた場合は走査を中止してctxのエラーを返す
+func WalkMusicDirs(ctx context.Context, dirPath string, fn func(dir string, files []string) error, onError func(dir string, err error)) error {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return err
 	}
+	return walkMusicDirs(ctx, dirPath, entries, fn, onError)
+}
 
-	return filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// walkMusicDirs は読み取り済みのディレクトリ dir とその下を走査する
+func walkMusicDirs(ctx context.Context, dir string, entries []os.DirEntry, fn func(dir string, files []string) error, onError func(dir string, err error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-		if info.IsDir() {
-			return nil
+	var files, subdirs []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			subdirs = append(subdirs, path)
+		} else if IsMusicFile(path) {
+			files = append(files, path)
 		}
+	}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		if !musicExtensions[ext] {
-			return nil
+	if len(files) > 0 {
+		if err := fn(dir, files); err != nil {
+			return err
 		}
+	}
 
-		if err := processFunc(path); err != nil {
-			fmt.Printf("エラー (%s): %v\n\n", path, err)
+	for _, subdir := range subdirs {
+		entries, err := os.ReadDir(subdir)
+		if err != nil {
+			onError(subdir, err)
+			continue
 		}
-
-		return nil
-	})
-}
\ No newline at end of file
+		if err := walkMusicDirs(ctx, subdir, entries, fn, onError); err != nil {
+			return err
+		}
+	}
+	return nil
+}
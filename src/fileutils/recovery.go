@@ -0,0 +1,159 @@
+package fileutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 埋め込み処理中に元ファイルの隣に作成するファイルの接尾辞
+const (
+	BackupSuffix = ".backup" // 元ファイルのバックアップ
+	TempSuffix   = ".tmp"    // ffmpegの出力先
+)
+
+// Orphan は中断などで残ったバックアップ・一時ファイルと、その元ファイルの組
+type Orphan struct {
+	Path       string // 元ファイルのパス（存在しない場合もある）
+	BackupPath string // バックアップのパス（なければ空）
+	TempPath   string // 一時ファイルのパス（なければ空）
+}
+
+// Recovery は残ったファイルの復旧方法
+type Recovery struct {
+	Orphan
+	Source string // 元ファイルとして残す正常なファイル（正常なファイルがなければ空）
+}
+
+// FindOrphans はファイルまたはディレクトリ内に残った音楽ファイルのバックアップ・一時ファイルを探す
+func FindOrphans(ctx context.Context, path string) ([]Orphan, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*Orphan)
+	add := func(sidecar string) {
+		original, suffix := splitSidecar(sidecar)
+		if original == "" {
+			return
+		}
+		orphan, ok := found[original]
+		if !ok {
+			orphan = &Orphan{Path: original}
+			found[original] = orphan
+		}
+		if suffix == BackupSuffix {
+			orphan.BackupPath = sidecar
+		} else {
+			orphan.TempPath = sidecar
+		}
+	}
+
+	if info.IsDir() {
+		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				add(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, suffix := range []string{BackupSuffix, TempSuffix} {
+			if _, err := os.Stat(path + suffix); err == nil {
+				add(path + suffix)
+			}
+		}
+	}
+
+	orphans := make([]Orphan, 0, len(found))
+	for _, orphan := range found {
+		orphans = append(orphans, *orphan)
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Path < orphans[j].Path
+	})
+	return orphans, nil
+}
+
+// splitSidecar はバックアップ・一時ファイルのパスを元ファイルのパスと接尾辞に分ける
+// 音楽ファイルのバックアップ・一時ファイルでなければ空文字列を返す
+func splitSidecar(path string) (string, string) {
+	for _, suffix := range []string{BackupSuffix, TempSuffix} {
+		if original := strings.TrimSuffix(path, suffix); original != path && IsMusicFile(original) {
+			return original, suffix
+		}
+	}
+	return "", ""
+}
+
+// PlanRecovery は元ファイル・バックアップ・一時ファイルを検証し、残すファイルを決める
+// 元ファイル、バックアップ（埋め込み前の内容）、一時ファイル（埋め込み後の内容）の順に、最初に正常と判定されたものを残す
+func PlanRecovery(ctx context.Context, orphan Orphan) (Recovery, error) {
+	recovery := Recovery{Orphan: orphan}
+	for _, candidate := range []string{orphan.Path, orphan.BackupPath, orphan.TempPath} {
+		if candidate == "" {
+			continue
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+		err := ValidateAudioFile(ctx, candidate)
+		if ctx.Err() != nil {
+			return recovery, ctx.Err()
+		}
+		if err == nil {
+			recovery.Source = candidate
+			break
+		}
+	}
+	return recovery, nil
+}
+
+// Describe は復旧方法を表示用の文字列にする
+func (r Recovery) Describe() string {
+	switch r.Source {
+	case "":
+		return "正常なファイルが見つからないため、そのまま残します"
+	case r.Path:
+		return "元ファイルは正常です。残ったファイルを削除します"
+	case r.BackupPath:
+		return "バックアップから元ファイルを復元します"
+	default:
+		return "埋め込み済みの一時ファイルを元ファイルとして使用します"
+	}
+}
+
+// Apply は正常なファイルを元ファイルの位置に置き、残りのバックアップ・一時ファイルを削除する
+func (r Recovery) Apply() error {
+	if r.Source == "" {
+		return fmt.Errorf("正常なファイルが見つかりません: %s", r.Path)
+	}
+
+	if r.Source != r.Path {
+		if err := os.Rename(r.Source, r.Path); err != nil {
+			return fmt.Errorf("元ファイルの復元に失敗: %w", err)
+		}
+	}
+
+	for _, sidecar := range []string{r.BackupPath, r.TempPath} {
+		if sidecar == "" || sidecar == r.Source {
+			continue
+		}
+		if err := os.Remove(sidecar); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("残ったファイルの削除に失敗: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package itunes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"music-artwork-embedder/src/provider"
+)
+
+// ProviderName は設定で使用するiTunesプロバイダー名
+const ProviderName = "itunes"
+
+const (
+	// DefaultBaseURL はiTunes Search APIのデフォルトURL
+	DefaultBaseURL = "https://itunes.apple.com"
+	// DefaultArtworkSize は取得するアートワークのデフォルトの一辺のピクセル数
+	DefaultArtworkSize = 3000
+
+	searchLimit = 5
+)
+
+// artworkSizePattern はアートワークURL中のサイズ指定部分（例: "100x100bb.jpg"）
+var artworkSizePattern = regexp.MustCompile(`/\d+x\d+bb\.(jpg|png)$`)
+
+// Client はiTunes Search APIクライアント（認証不要）
+type Client struct {
+	baseURL     string
+	artworkSize int
+	httpClient  *http.Client
+}
+
+// NewClient は新しいiTunesクライアントを作成（空のURL・0以下のサイズはデフォルトを使用）
+// transport がnilの場合は http.DefaultTransport を使用
+func NewClient(baseURL string, artworkSize int, transport http.RoundTripper) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if artworkSize <= 0 {
+		artworkSize = DefaultArtworkSize
+	}
+
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		artworkSize: artworkSize,
+		httpClient:  &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+}
+
+// Name はプロバイダー名を返す
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// Search はiTunes Search APIでアートワーク候補を検索（アルバム名があればアルバム検索）
+func (c *Client) Search(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	params := url.Values{}
+	params.Set("media", "music")
+	params.Set("limit", fmt.Sprint(searchLimit))
+
+	if query.Album != "" {
+		params.Set("entity", "album")
+		params.Set("term", strings.TrimSpace(query.AlbumSearchArtist()+" "+query.Album))
+	} else if query.Title != "" {
+		params.Set("entity", "song")
+		params.Set("term", strings.TrimSpace(query.Artist+" "+query.Title))
+	} else {
+		return nil, fmt.Errorf("検索に必要な情報が不足しています: %w", provider.ErrNotFound)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/search?%s", c.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("iTunes検索に失敗: %d", resp.StatusCode)
+	}
+
+	var searchResp SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, err
+	}
+
+	var candidates []provider.Candidate
+	for _, result := range searchResp.Results {
+		if result.ArtworkURL100 == "" {
+			continue
+		}
+
+		candidates = append(candidates, provider.Candidate{
+			Provider: ProviderName,
+			// 書き換えたURLの画像が指定サイズとは限らない（原画像が小さい場合など）ため、サイズはダウンロード後に取得する
+			ImageURL: HighResolutionURL(result.ArtworkURL100, c.artworkSize),
+			Artist:   result.ArtistName,
+			Album:    result.CollectionName,
+			Title:    result.TrackName,
+			Duration: time.Duration(result.TrackTimeMillis) * time.Millisecond,
+		})
+	}
+
+	if len(candidates) == 0 {
+		return nil, provider.ErrNotFound
+	}
+
+	return candidates, nil
+}
+
+// HighResolutionURL はartworkUrl100のサイズ指定を書き換え、指定サイズの画像URLを返す
+func HighResolutionURL(artworkURL string, size int) string {
+	return artworkSizePattern.ReplaceAllString(artworkURL, fmt.Sprintf("/%dx%dbb.$1", size, size))
+}
@@ -0,0 +1,14 @@
+package itunes
+
+// SearchResponse はiTunes Search APIのレスポンス構造体
+type SearchResponse struct {
+	ResultCount int `json:"resultCount"`
+	Results     []struct {
+		WrapperType     string `json:"wrapperType"`
+		ArtistName      string `json:"artistName"`
+		CollectionName  string `json:"collectionName"`
+		TrackName       string `json:"trackName"`
+		TrackTimeMillis int    `json:"trackTimeMillis"`
+		ArtworkURL100   string `json:"artworkUrl100"`
+	} `json:"results"`
+}
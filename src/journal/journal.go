@@ -0,0 +1,159 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileName はジャーナルファイルの名前（処理対象ディレクトリ、または出力先ディレクトリに作成）
+const FileName = ".music-artwork-embedder-journal.jsonl"
+
+// ジャーナルに記録する処理状態
+const (
+	StatusDone   = "done"   // 処理済み（埋め込み・置き換え・スキップ）
+	StatusFailed = "failed" // 失敗（再開時に再試行する）
+)
+
+// Entry はジャーナルの1行（1ファイル分の処理状態）
+type Entry struct {
+	Path    string `json:"path"`     // 処理対象ディレクトリからの相対パス
+	ModTime int64  `json:"mtime_ns"` // 処理後のファイルの更新時刻（UnixNano）
+	Size    int64  `json:"size"`     // 処理後のファイルサイズ
+	Status  string `json:"status"`
+}
+
+// Journal はディレクトリ処理の進捗を記録する（複数のゴルーチンから安全に呼び出せる）
+type Journal struct {
+	mu      sync.Mutex
+	dir     string
+	path    string
+	file    *os.File
+	entries map[string]Entry // 前回までの実行で記録された状態（再開時のみ）
+}
+
+// Open は journalDir のジャーナルを開き、baseDir 以下のファイルの処理状態を記録する
+// resume がtrueの場合は既存の記録を読み込んで追記し、falseの場合は新しく作り直す
+func Open(journalDir, baseDir string, resume bool) (*Journal, error) {
+	j := &Journal{
+		dir:     baseDir,
+		path:    filepath.Join(journalDir, FileName),
+		entries: make(map[string]Entry),
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		if err := j.load(); err != nil {
+			return nil, err
+		}
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(j.path, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ジャーナルファイルを開けません: %w", err)
+	}
+	j.file = file
+	return j, nil
+}
+
+// load は既存のジャーナルを読み込む（同じファイルの記録は後のものを優先）
+func (j *Journal) load() error {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ジャーナルファイルを読み込めません: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		// 中断時に書きかけになった行は無視する
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		j.entries[entry.Path] = entry
+	}
+	return scanner.Err()
+}
+
+// Completed は前回までの実行で処理済みで、その後ファイルが変更されていないかを返す
+func (j *Journal) Completed(filePath string) bool {
+	rel, err := filepath.Rel(j.dir, filePath)
+	if err != nil {
+		return false
+	}
+
+	j.mu.Lock()
+	entry, ok := j.entries[rel]
+	j.mu.Unlock()
+	if !ok || entry.Status != StatusDone {
+		return false
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+	return info.ModTime().UnixNano() == entry.ModTime && info.Size() == entry.Size
+}
+
+// Record はファイルの処理状態を現在の更新時刻・サイズとともに記録する
+func (j *Journal) Record(filePath, status string) error {
+	rel, err := filepath.Rel(j.dir, filePath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	entry := Entry{
+		Path:    rel,
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Status:  status,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("ジャーナルは閉じられています")
+	}
+	// 中断された場合でも記録が残るよう、1件ごとに書き込む
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close はジャーナルファイルを閉じる（複数回の呼び出しにも対応）
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// Remove はジャーナルファイルを閉じて削除する
+func (j *Journal) Remove() error {
+	if err := j.Close(); err != nil {
+		return err
+	}
+	return os.Remove(j.path)
+}
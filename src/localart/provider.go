@@ -0,0 +1,106 @@
+package localart
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"music-artwork-embedder/src/artwork"
+	"music-artwork-embedder/src/provider"
+)
+
+// ProviderName は設定で使用するローカル画像プロバイダー名
+const ProviderName = "local"
+
+// DefaultPatterns は音楽ファイルと同じフォルダで探す画像ファイル名のパターン（大文字小文字は区別しない）
+var DefaultPatterns = []string{"cover.*", "folder.*", "front.*", "albumart*.jpg"}
+
+// imageExtensions は埋め込み対象とする画像の拡張子
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// Provider は音楽ファイルと同じフォルダにある画像をアートワークとして使用する
+type Provider struct {
+	patterns []string
+}
+
+// NewProvider は新しいローカル画像プロバイダーを作成（追加パターンはデフォルトより先に試行）
+func NewProvider(extraPatterns []string) *Provider {
+	var patterns []string
+	for _, list := range [][]string{extraPatterns, DefaultPatterns} {
+		for _, pattern := range list {
+			patterns = append(patterns, strings.ToLower(pattern))
+		}
+	}
+	return &Provider{patterns: patterns}
+}
+
+// Name はプロバイダー名を返す
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Search は音楽ファイルと同じフォルダからパターンに一致する画像を探す
+func (p *Provider) Search(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if query.FilePath == "" {
+		return nil, fmt.Errorf("音楽ファイルのパスが指定されていません")
+	}
+
+	dir := filepath.Dir(query.FilePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []provider.Candidate
+	used := make(map[string]bool)
+	for _, pattern := range p.patterns {
+		var matched []provider.Candidate
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || used[name] || !imageExtensions[strings.ToLower(filepath.Ext(name))] {
+				continue
+			}
+			if ok, _ := filepath.Match(pattern, strings.ToLower(name)); !ok {
+				continue
+			}
+
+			imagePath := filepath.Join(dir, name)
+			width, height, err := artwork.ImageSize(imagePath)
+			if err != nil {
+				continue
+			}
+
+			used[name] = true
+			matched = append(matched, provider.Candidate{
+				Provider:  ProviderName,
+				LocalPath: imagePath,
+				Width:     width,
+				Height:    height,
+				Exact:     true,
+			})
+		}
+
+		// 同じパターン内では大きい画像を優先（AlbumArtSmall.jpg など縮小版を避ける）
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].Width*matched[i].Height > matched[j].Width*matched[j].Height
+		})
+		candidates = append(candidates, matched...)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("フォルダ内に画像がありません: %w", provider.ErrNotFound)
+	}
+
+	return candidates, nil
+}
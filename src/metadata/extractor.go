@@ -1,24 +1,140 @@
 package metadata
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/dhowden/tag"
+	"github.com/dhowden/tag/mbz"
 )
 
+// TrackMetadata は音楽ファイルのタグから読み取った情報
+type TrackMetadata struct {
+	Artist      string
+	AlbumArtist string // アルバムアーティスト（コンピレーションでは曲ごとのアーティストと異なる）
+	Album       string
+	Title       string
+	Composer    string
+	Genre       string
+	Year        int
+
+	TrackNumber int
+	TrackTotal  int
+	DiscNumber  int
+	DiscTotal   int
+
+	ISRC    string // 曲の国際標準レコーディングコード
+	Barcode string // アルバムのバーコード（UPC/EAN）
+
+	MusicBrainz MusicBrainzIDs
+}
+
+// MusicBrainzIDs はタグに記録されたMusicBrainzの各種ID
+type MusicBrainzIDs struct {
+	ReleaseID      string
+	ReleaseGroupID string
+	RecordingID    string
+	TrackID        string
+	ArtistID       string
+	AlbumArtistID  string
+}
+
+// AlbumSearchArtist はアルバム検索に使うアーティスト（アルバムアーティストがあれば優先）を返す
+func (m TrackMetadata) AlbumSearchArtist() string {
+	if m.AlbumArtist != "" {
+		return m.AlbumArtist
+	}
+	return m.Artist
+}
+
 // ExtractMetadata は音楽ファイルからメタデータを抽出
-func ExtractMetadata(filePath string) (artist, album, title string, err error) {
+// タグがないファイルはエラーにせず、空のメタデータを返す（ファイル名などから補完するため）
+func ExtractMetadata(filePath string) (TrackMetadata, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return "", "", "", fmt.Errorf("ファイルを開けませんでした: %w", err)
+		return TrackMetadata{}, fmt.Errorf("ファイルを開けませんでした: %w", err)
 	}
 	defer file.Close()
 
-	metadata, err := tag.ReadFrom(file)
+	m, err := tag.ReadFrom(file)
+	if errors.Is(err, tag.ErrNoTagsFound) {
+		return TrackMetadata{}, nil
+	}
 	if err != nil {
-		return "", "", "", fmt.Errorf("メタデータを読み取れませんでした: %w", err)
+		return TrackMetadata{}, fmt.Errorf("メタデータを読み取れませんでした: %w", err)
+	}
+
+	return fromTag(m), nil
+}
+
+// fromTag は読み取ったタグを TrackMetadata に変換する
+// タグの生データ（埋め込み画像を含む）は必要な項目を取り出すだけで保持しない
+func fromTag(m tag.Metadata) TrackMetadata {
+	raw := m.Raw()
+	md := TrackMetadata{
+		Artist:      strings.TrimSpace(m.Artist()),
+		AlbumArtist: strings.TrimSpace(m.AlbumArtist()),
+		Album:       strings.TrimSpace(m.Album()),
+		Title:       strings.TrimSpace(m.Title()),
+		Composer:    strings.TrimSpace(m.Composer()),
+		Genre:       strings.TrimSpace(m.Genre()),
+		Year:        m.Year(),
 	}
+	md.TrackNumber, md.TrackTotal = m.Track()
+	md.DiscNumber, md.DiscTotal = m.Disc()
 
-	return metadata.Artist(), metadata.Album(), metadata.Title(), nil
-}
\ No newline at end of file
+	ids := mbz.Extract(m)
+	md.MusicBrainz = MusicBrainzIDs{
+		ReleaseID:      ids.Get(mbz.Album),
+		ReleaseGroupID: ids.Get(mbz.ReleaseGroup),
+		RecordingID:    ids.Get(mbz.Recording),
+		TrackID:        ids.Get(mbz.Track),
+		ArtistID:       ids.Get(mbz.Artist),
+		AlbumArtistID:  ids.Get(mbz.AlbumArtist),
+	}
+
+	switch m.Format() {
+	case tag.ID3v2_2, tag.ID3v2_3, tag.ID3v2_4:
+		md.ISRC = rawText(raw, "TSRC", "TRC")
+		md.Barcode = userText(raw, "BARCODE", "UPC", "EAN")
+	default:
+		// Vorbisコメントのキーは小文字、MP4のフリーフォームは名前のまま
+		md.ISRC = rawText(raw, "isrc", "ISRC")
+		md.Barcode = rawText(raw, "barcode", "BARCODE", "upc", "UPC", "ean", "EAN")
+	}
+	md.ISRC = strings.ToUpper(strings.ReplaceAll(md.ISRC, "-", ""))
+	return md
+}
+
+// rawText は生データから最初に見つかった文字列の値を返す
+func rawText(raw map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := raw[key].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// userText はID3のユーザー定義テキスト（TXXX）から説明が descriptions のいずれかに一致する値を返す
+// 同じフレームが複数ある場合、キーは TXXX, TXXX_0, TXXX_1... となる
+func userText(raw map[string]interface{}, descriptions ...string) string {
+	for _, desc := range descriptions {
+		for key, value := range raw {
+			// ID3v2.2 では TXX
+			if !strings.HasPrefix(key, "TXX") {
+				continue
+			}
+			comm, ok := value.(*tag.Comm)
+			if !ok || !strings.EqualFold(comm.Description, desc) {
+				continue
+			}
+			if text := strings.TrimSpace(comm.Text); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
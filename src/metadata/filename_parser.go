@@ -1,7 +1,6 @@
 package metadata
 
 import (
-	"fmt"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -37,7 +36,5 @@ func ExtractTitleFromFilename(filePath string) string {
 	// アンダースコアをスペースに変換（オプション）
 	cleanTitle = strings.ReplaceAll(cleanTitle, "_", " ")
 
-	fmt.Printf("    ファイル名から抽出: '%s' -> '%s'\n", titleWithoutExt, cleanTitle)
-
 	return cleanTitle
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+package metadata
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// パターンで使用できるプレースホルダーと、一致させる正規表現
+var placeholderExprs = map[string]string{
+	"artist":      `(.+?)`,
+	"albumartist": `(.+?)`,
+	"album":       `(.+?)`,
+	"title":       `(.+?)`,
+	"track":       `(\d{1,3})`,
+	"disc":        `(\d{1,2})`,
+	"year":        `(\d{4})`,
+}
+
+var placeholderRe = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// FilenamePattern はタグのないファイルのパスからメタデータを取り出すテンプレート
+// 例: "{track} - {artist} - {title}"、"{artist}/{album}/{track} {title}"
+// "/" で区切った各要素はファイルパスの末尾の要素（最後はファイル名から拡張子を除いたもの）と対応する
+type FilenamePattern struct {
+	template string
+	depth    int // テンプレートが対応するパスの要素数
+	re       *regexp.Regexp
+	fields   []string // 正規表現のグループ順のプレースホルダー名
+}
+
+// ParseFilenamePattern はテンプレートを解析する
+// 未知のプレースホルダーや、同じプレースホルダーの重複はエラーにする
+func ParseFilenamePattern(template string) (*FilenamePattern, error) {
+	template = strings.Trim(filepath.ToSlash(strings.TrimSpace(template)), "/")
+	if template == "" {
+		return nil, fmt.Errorf("ファイル名パターンが空です")
+	}
+
+	p := &FilenamePattern{
+		template: template,
+		depth:    strings.Count(template, "/") + 1,
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	seen := make(map[string]bool)
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringSubmatchIndex(template, -1) {
+		name := template[loc[2]:loc[3]]
+		fieldExpr, ok := placeholderExprs[name]
+		if !ok {
+			return nil, fmt.Errorf("ファイル名パターン %q の {%s} は使用できません", template, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("ファイル名パターン %q で {%s} が重複しています", template, name)
+		}
+		seen[name] = true
+
+		expr.WriteString(literalExpr(template[last:loc[0]]))
+		expr.WriteString(fieldExpr)
+		p.fields = append(p.fields, name)
+		last = loc[1]
+	}
+	expr.WriteString(literalExpr(template[last:]))
+	expr.WriteString("$")
+
+	if len(p.fields) == 0 {
+		return nil, fmt.Errorf("ファイル名パターン %q にプレースホルダーがありません", template)
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("ファイル名パターン %q を解析できません: %w", template, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// literalExpr はテンプレートの固定部分を正規表現にする（空白は1文字以上の任意の空白に一致させる）
+func literalExpr(literal string) string {
+	parts := strings.Fields(literal)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := strings.Join(parts, `\s+`)
+	if strings.TrimLeft(literal, " \t") != literal {
+		expr = `\s+` + expr
+	}
+	if strings.TrimRight(literal, " \t") != literal && strings.TrimSpace(literal) != "" {
+		expr += `\s+`
+	}
+	return expr
+}
+
+// String はテンプレートを返す
+func (p *FilenamePattern) String() string {
+	return p.template
+}
+
+// Match はファイルパスの末尾がテンプレートに一致すれば、取り出したメタデータを返す
+func (p *FilenamePattern) Match(filePath string) (TrackMetadata, bool) {
+	components := strings.Split(filepath.ToSlash(filepath.Clean(filePath)), "/")
+	if len(components) < p.depth {
+		return TrackMetadata{}, false
+	}
+	components = components[len(components)-p.depth:]
+	name := components[len(components)-1]
+	components[len(components)-1] = strings.TrimSuffix(name, filepath.Ext(name))
+
+	groups := p.re.FindStringSubmatch(strings.Join(components, "/"))
+	if groups == nil {
+		return TrackMetadata{}, false
+	}
+
+	var md TrackMetadata
+	for i, field := range p.fields {
+		value := strings.TrimSpace(strings.ReplaceAll(groups[i+1], "_", " "))
+		switch field {
+		case "artist":
+			md.Artist = value
+		case "albumartist":
+			md.AlbumArtist = value
+		case "album":
+			md.Album = value
+		case "title":
+			md.Title = value
+		case "track":
+			md.TrackNumber, _ = strconv.Atoi(value)
+		case "disc":
+			md.DiscNumber, _ = strconv.Atoi(value)
+		case "year":
+			md.Year, _ = strconv.Atoi(value)
+		}
+	}
+	return md, true
+}
+
+// MatchFilename はパターンを順に試し、最初に一致したパターンと取り出したメタデータを返す
+func MatchFilename(patterns []*FilenamePattern, filePath string) (TrackMetadata, *FilenamePattern, bool) {
+	for _, p := range patterns {
+		if md, ok := p.Match(filePath); ok {
+			return md, p, true
+		}
+	}
+	return TrackMetadata{}, nil, false
+}
+
+// FillMissing はタグにない項目を from の値で補い、補った項目名を返す
+func (m *TrackMetadata) FillMissing(from TrackMetadata) []string {
+	var filled []string
+	fillText := func(name string, dst *string, src string) {
+		if *dst == "" && src != "" {
+			*dst = src
+			filled = append(filled, name)
+		}
+	}
+	fillNumber := func(name string, dst *int, src int) {
+		if *dst == 0 && src != 0 {
+			*dst = src
+			filled = append(filled, name)
+		}
+	}
+
+	fillText("artist", &m.Artist, from.Artist)
+	fillText("albumartist", &m.AlbumArtist, from.AlbumArtist)
+	fillText("album", &m.Album, from.Album)
+	fillText("title", &m.Title, from.Title)
+	fillNumber("track", &m.TrackNumber, from.TrackNumber)
+	fillNumber("disc", &m.DiscNumber, from.DiscNumber)
+	fillNumber("year", &m.Year, from.Year)
+	return filled
+}
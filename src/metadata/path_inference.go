@@ -0,0 +1,122 @@
+package metadata
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// "CD1"、"Disc 2" などのディスクごとのフォルダ
+	discFolderRe = regexp.MustCompile(`(?i)^(?:cd|dis[ck])[\s_\-]*(\d{1,2})$`)
+	// "Artist - Album" 形式のフォルダ名
+	artistAlbumRe = regexp.MustCompile(`^(.+?)\s+-\s+(.+)$`)
+	// 末尾に "(1975)" や "[1975]" の年が付いたフォルダ名
+	folderYearRe = regexp.MustCompile(`^(.+?)\s*[\(\[](\d{4})[\)\]]$`)
+	// 先頭に曲番号が付いたファイル名（"01 Title"、"01. Title" など）
+	trackPrefixRe = regexp.MustCompile(`^(\d{1,3})[\s\.\-_]+\S`)
+)
+
+// libraryFolders はアーティスト・アルバム名とみなさない、ライブラリの置き場所として一般的なフォルダ名（小文字）
+var libraryFolders = map[string]bool{
+	"music": true, "my music": true, "itunes": true, "itunes media": true, "media": true,
+	"downloads": true, "desktop": true, "home": true, "users": true, "ミュージック": true,
+}
+
+// InferFromPath は慣例的なフォルダ構成からメタデータを推測する
+//
+//   - ファイル名 "NN Title.ext" から曲番号と曲名
+//   - フォルダ名 "Artist - Album (Year)" からアーティスト・アルバム名・年
+//   - 曲番号付きのファイルが "Artist/Album/" に置かれていれば、親フォルダをアルバム名、その親をアーティストとみなす
+//
+// "CD1"、"Disc 2" などのフォルダはディスク番号として扱い、その親フォルダから推測する
+//
+// 処理対象のディレクトリ rootDir より下にあるフォルダは、rootDir より上のフォルダ名を使わない（ホームディレクトリ名などを検索条件にしないため）
+// ファイルが rootDir（またはそのディスクのフォルダ）の直下にある場合は rootDir をアルバムのフォルダとみなし、その親をアーティストとする
+// （単一ファイルの処理では rootDir はファイルのフォルダ）
+// rootDir が空の場合は制限しない
+func InferFromPath(filePath, rootDir string) TrackMetadata {
+	filePath = absPath(filePath)
+	if rootDir != "" {
+		rootDir = absPath(rootDir)
+	}
+	// inTree は dir が rootDir 以下（strict の場合は rootDir より下）にあるかを返す
+	inTree := func(dir string, strict bool) bool {
+		if rootDir == "" {
+			return true
+		}
+		rel, err := filepath.Rel(rootDir, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return false
+		}
+		return !strict || rel != "."
+	}
+
+	var md TrackMetadata
+	name := filepath.Base(filePath)
+	numbered := false
+	if m := trackPrefixRe.FindStringSubmatch(strings.TrimSuffix(name, filepath.Ext(name))); m != nil {
+		md.TrackNumber, _ = strconv.Atoi(m[1])
+		numbered = true
+	}
+	md.Title = ExtractTitleFromFilename(filePath)
+
+	dir := filepath.Dir(filePath)
+	// rootDir がアルバム（またはそのディスク）のフォルダ自体か
+	albumRoot := rootDir == "" || dir == rootDir
+	if m := discFolderRe.FindStringSubmatch(filepath.Base(dir)); m != nil && inTree(dir, false) {
+		md.DiscNumber, _ = strconv.Atoi(m[1])
+		dir = filepath.Dir(dir)
+		albumRoot = albumRoot || dir == rootDir
+	}
+
+	if !albumRoot && !inTree(dir, false) {
+		return md
+	}
+	album := folderName(dir)
+	if album == "" {
+		return md
+	}
+	if m := folderYearRe.FindStringSubmatch(album); m != nil {
+		album = m[1]
+		md.Year, _ = strconv.Atoi(m[2])
+	}
+
+	if m := artistAlbumRe.FindStringSubmatch(album); m != nil {
+		md.Artist = strings.TrimSpace(m[1])
+		md.Album = strings.TrimSpace(m[2])
+		return md
+	}
+
+	// 曲番号のないファイルは慣例的な構成に置かれているとは限らないため、フォルダ名を使わない
+	if !numbered || (!albumRoot && !inTree(dir, true)) {
+		md.Year = 0
+		return md
+	}
+	md.Album = album
+	if artistDir := filepath.Dir(dir); albumRoot || inTree(artistDir, true) {
+		md.Artist = folderName(artistDir)
+	}
+	return md
+}
+
+// absPath は絶対パスを返す（変換できない場合はそのまま返す）
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// folderName はフォルダ名を返す（ルートやライブラリの置き場所の場合は空文字）
+func folderName(dir string) string {
+	if filepath.Dir(dir) == dir {
+		return ""
+	}
+	name := strings.TrimSpace(strings.ReplaceAll(filepath.Base(dir), "_", " "))
+	if libraryFolders[strings.ToLower(name)] {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,250 @@
+package musicbrainz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"music-artwork-embedder/src/provider"
+)
+
+// ProviderName は設定で使用するMusicBrainzプロバイダー名
+const ProviderName = "musicbrainz"
+
+const (
+	// DefaultBaseURL はMusicBrainz Web Service (v2) のデフォルトURL
+	DefaultBaseURL = "https://musicbrainz.org/ws/2"
+	// DefaultCoverArtBaseURL はCover Art ArchiveのデフォルトURL
+	DefaultCoverArtBaseURL = "https://coverartarchive.org"
+
+	userAgent = "music-artwork-embedder/1.0 ( https://github.com/mormorbump/import-song-asset-metadata )"
+
+	// MusicBrainzの利用規約上、リクエストは1秒に1回まで
+	requestInterval = time.Second
+	searchLimit     = 5
+)
+
+// Client はMusicBrainz + Cover Art Archive APIクライアント
+type Client struct {
+	baseURL         string
+	coverArtBaseURL string
+	httpClient      *http.Client
+
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+// NewClient は新しいMusicBrainzクライアントを作成（空のURLはデフォルトを使用）
+// transport がnilの場合は http.DefaultTransport を使用
+func NewClient(baseURL, coverArtBaseURL string, transport http.RoundTripper) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if coverArtBaseURL == "" {
+		coverArtBaseURL = DefaultCoverArtBaseURL
+	}
+
+	return &Client{
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		coverArtBaseURL: strings.TrimRight(coverArtBaseURL, "/"),
+		httpClient:      &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+}
+
+// Name はプロバイダー名を返す
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// Search はMusicBrainzでリリースを特定し、Cover Art Archiveから表紙画像の候補を返す
+func (c *Client) Search(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	var releases []Release
+	if query.MusicBrainz.ReleaseID != "" {
+		// タグにMBIDがあれば検索せず直接使用
+		releases = []Release{{ID: query.MusicBrainz.ReleaseID, Title: query.Album}}
+	} else {
+		var err error
+		releases, err = c.searchReleases(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var candidates []provider.Candidate
+	var fetchErr error // 表紙画像の取得時に発生した該当なし以外のエラー
+	for _, release := range releases {
+		imageURL, err := c.frontCoverURL(ctx, release.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !errors.Is(err, provider.ErrNotFound) && fetchErr == nil {
+				fetchErr = err
+			}
+			continue
+		}
+
+		candidates = append(candidates, provider.Candidate{
+			Provider: ProviderName,
+			ImageURL: imageURL,
+			Artist:   joinArtistCredit(release.ArtistCredit),
+			Album:    release.Title,
+			Exact:    query.MusicBrainz.ReleaseID != "",
+		})
+	}
+
+	if len(candidates) == 0 {
+		// 通信エラーで確認できなかったリリースがあれば、該当なしとは区別して返す
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, provider.ErrNotFound
+	}
+
+	return candidates, nil
+}
+
+// searchReleases はアルバム名（なければ曲名）とアーティスト名でリリースを検索
+// アルバム検索ではアルバムアーティスト（なければアーティスト）を使う
+func (c *Client) searchReleases(ctx context.Context, query provider.Query) ([]Release, error) {
+	var terms []string
+	if query.Album != "" {
+		if artist := query.AlbumSearchArtist(); artist != "" {
+			terms = append(terms, fmt.Sprintf(`artist:"%s"`, escapeLucene(artist)))
+		}
+		terms = append(terms, fmt.Sprintf(`release:"%s"`, escapeLucene(query.Album)))
+
+		var resp ReleaseSearchResponse
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.getJSON(ctx, c.searchURL("release", terms), &resp); err != nil {
+			return nil, err
+		}
+		return resp.Releases, nil
+	}
+
+	if query.Title == "" {
+		return nil, fmt.Errorf("検索に必要な情報が不足しています: %w", provider.ErrNotFound)
+	}
+
+	// アルバム名がない場合はレコーディングを検索し、収録リリースを候補にする
+	if query.Artist != "" {
+		terms = append(terms, fmt.Sprintf(`artist:"%s"`, escapeLucene(query.Artist)))
+	}
+	terms = append(terms, fmt.Sprintf(`recording:"%s"`, escapeLucene(query.Title)))
+
+	var resp RecordingSearchResponse
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.getJSON(ctx, c.searchURL("recording", terms), &resp); err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+	for _, recording := range resp.Recordings {
+		for _, release := range recording.Releases {
+			if len(release.ArtistCredit) == 0 {
+				release.ArtistCredit = recording.ArtistCredit
+			}
+			releases = append(releases, release)
+			if len(releases) >= searchLimit {
+				return releases, nil
+			}
+		}
+	}
+	return releases, nil
+}
+
+// searchURL はMusicBrainz検索APIのURLを組み立てる
+func (c *Client) searchURL(entity string, terms []string) string {
+	params := url.Values{}
+	params.Set("query", strings.Join(terms, " AND "))
+	params.Set("fmt", "json")
+	params.Set("limit", fmt.Sprint(searchLimit))
+	return fmt.Sprintf("%s/%s/?%s", c.baseURL, entity, params.Encode())
+}
+
+// frontCoverURL はCover Art Archiveからリリースの表紙（原寸）画像URLを取得
+func (c *Client) frontCoverURL(ctx context.Context, releaseID string) (string, error) {
+	var resp CoverArtResponse
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/release/%s", c.coverArtBaseURL, url.PathEscape(releaseID)), &resp); err != nil {
+		return "", err
+	}
+
+	for _, image := range resp.Images {
+		if image.Front && image.Image != "" {
+			return image.Image, nil
+		}
+	}
+
+	return "", fmt.Errorf("表紙画像が登録されていません: %s: %w", releaseID, provider.ErrNotFound)
+}
+
+// getJSON はGETリクエストを送信し、レスポンスをJSONとして解析
+func (c *Client) getJSON(ctx context.Context, requestURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Cover Art Archiveは画像が登録されていないリリースに404を返す
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("登録されていません (%s): %w", requestURL, provider.ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("リクエストに失敗: %d (%s)", resp.StatusCode, requestURL)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// wait はMusicBrainz APIのレート制限を守るため前回のリクエストから一定時間待機
+// ctx がキャンセルされた場合は待機を中止してそのエラーを返す
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elapsed := time.Since(c.lastRequest); elapsed < requestInterval {
+		timer := time.NewTimer(requestInterval - elapsed)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	c.lastRequest = time.Now()
+	return nil
+}
+
+// joinArtistCredit はアーティストクレジットを表示用の文字列に結合
+func joinArtistCredit(credits []ArtistCredit) string {
+	names := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		names = append(names, credit.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// escapeLucene は検索クエリのフレーズ内で特別な意味を持つ文字をエスケープ
+func escapeLucene(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,41 @@
+package musicbrainz
+
+// ArtistCredit はMusicBrainzのアーティストクレジット
+type ArtistCredit struct {
+	Name string `json:"name"`
+}
+
+// Release はMusicBrainzのリリース（アルバム）情報
+type Release struct {
+	ID           string         `json:"id"`
+	Score        int            `json:"score"`
+	Title        string         `json:"title"`
+	ArtistCredit []ArtistCredit `json:"artist-credit"`
+}
+
+// ReleaseSearchResponse はMusicBrainzリリース検索APIのレスポンス構造体
+type ReleaseSearchResponse struct {
+	Releases []Release `json:"releases"`
+}
+
+// RecordingSearchResponse はMusicBrainzレコーディング検索APIのレスポンス構造体
+type RecordingSearchResponse struct {
+	Recordings []struct {
+		ID           string         `json:"id"`
+		Score        int            `json:"score"`
+		Title        string         `json:"title"`
+		ArtistCredit []ArtistCredit `json:"artist-credit"`
+		Releases     []Release      `json:"releases"`
+	} `json:"recordings"`
+}
+
+// CoverArtResponse はCover Art Archiveのリリース画像一覧レスポンス構造体
+type CoverArtResponse struct {
+	Images []struct {
+		Image      string            `json:"image"`
+		Front      bool              `json:"front"`
+		Types      []string          `json:"types"`
+		Thumbnails map[string]string `json:"thumbnails"`
+	} `json:"images"`
+	Release string `json:"release"`
+}
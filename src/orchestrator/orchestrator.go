@@ -1,164 +1,718 @@
 package orchestrator
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"music-artwork-embedder/src/artwork"
 	"music-artwork-embedder/src/config"
 	"music-artwork-embedder/src/fileutils"
+	"music-artwork-embedder/src/journal"
 	"music-artwork-embedder/src/metadata"
-	"music-artwork-embedder/src/spotify"
+	"music-artwork-embedder/src/provider"
+	"music-artwork-embedder/src/report"
 )
 
 // Orchestrator は各モジュールを協調させて処理を行う
 type Orchestrator struct {
 	config           *config.Config
-	spotifyClient    *spotify.Client
+	providers        []provider.ArtworkProvider
 	artworkProcessor *artwork.Processor
+	scratchDir       string                      // 実行ごとの作業用ディレクトリ（ダウンロード画像等を置く）
+	inputRoot        string                      // 処理対象のディレクトリ（出力先ディレクトリに再現するツリーの起点）
+	filenamePatterns []*metadata.FilenamePattern // タグのないファイルに試行順に当てはめるパターン
+	closeMu          sync.Mutex
+
+	tally         report.Tally     // 処理結果の集計
+	reporter      *report.Writer   // 処理結果のレポート（未指定ならnil）
+	journal       *journal.Journal // ディレクトリ処理の進捗（ディレクトリ処理中のみ）
+	recordErr     error            // 最初に発生したレポート・ジャーナルの書き込みエラー
+	recordErrOnce sync.Once
 }
 
 // NewOrchestrator は新しいオーケストレーターを作成
 func NewOrchestrator(cfg *config.Config) *Orchestrator {
 	return &Orchestrator{
 		config:           cfg,
-		spotifyClient:    spotify.NewClient(),
-		artworkProcessor: artwork.NewProcessor(),
+		artworkProcessor: artwork.NewProcessor(cfg.HTTPTransport),
 	}
 }
 
-// Initialize はSpotifyクライアントを初期化
-func (o *Orchestrator) Initialize() error {
-	// Spotifyトークンを取得
-	fmt.Println("Spotify API認証中...")
-	if err := o.spotifyClient.GetToken(o.config.SpotifyClientID, o.config.SpotifyClientSecret); err != nil {
-		return fmt.Errorf("Spotify認証エラー: %w", err)
+// Initialize はファイル名パターンを解析して作業用ディレクトリを作成し、設定順にアートワークプロバイダーを作成・初期化
+func (o *Orchestrator) Initialize(ctx context.Context) error {
+	for _, template := range o.config.FilenamePatterns {
+		pattern, err := metadata.ParseFilenamePattern(template)
+		if err != nil {
+			return err
+		}
+		o.filenamePatterns = append(o.filenamePatterns, pattern)
+	}
+
+	// 同時に実行した他のプロセスと一時ファイルが衝突しないよう、実行ごとに作業用ディレクトリを作成
+	scratchDir, err := os.MkdirTemp("", "music-artwork-embedder-*")
+	if err != nil {
+		return fmt.Errorf("作業用ディレクトリ作成エラー: %w", err)
+	}
+	o.scratchDir = scratchDir
+
+	// 処理結果のレポートファイルを作成
+	if o.config.ReportPath != "" {
+		reporter, err := report.Open(o.config.ReportPath)
+		if err != nil {
+			return fmt.Errorf("レポートファイル作成エラー: %w", err)
+		}
+		o.reporter = reporter
+	}
+
+	providers, err := newProviders(o.config)
+	if err != nil {
+		return err
 	}
+
+	// 初期化（認証など）に失敗したプロバイダーは除外し、残りのプロバイダーで続行する
+	var available []provider.ArtworkProvider
+	for _, p := range providers {
+		if initializer, ok := p.(provider.Initializer); ok {
+			if err := initializer.Initialize(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				fmt.Printf("警告: %sを初期化できないため除外します: %v\n", p.Name(), err)
+				continue
+			}
+		}
+		available = append(available, p)
+	}
+	if len(available) == 0 {
+		return fmt.Errorf("利用できるアートワークプロバイダーがありません")
+	}
+	if len(available) < len(providers) {
+		names := make([]string, 0, len(available))
+		for _, p := range available {
+			names = append(names, p.Name())
+		}
+		fmt.Printf("残りのプロバイダー (%s) で続行します\n", strings.Join(names, ", "))
+	}
+
+	o.providers = available
 	return nil
 }
 
+// Close はレポート・ジャーナルを閉じ、作業用ディレクトリを削除（シグナルハンドラーからの呼び出しや複数回の呼び出しにも対応）
+func (o *Orchestrator) Close() error {
+	o.closeMu.Lock()
+	defer o.closeMu.Unlock()
+
+	var err error
+	if o.reporter != nil {
+		err = o.reporter.Close()
+		o.reporter = nil
+	}
+	if o.journal != nil {
+		if closeErr := o.journal.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err == nil {
+		err = o.recordErr
+	}
+
+	if o.scratchDir != "" {
+		if removeErr := os.RemoveAll(o.scratchDir); err == nil {
+			err = removeErr
+		}
+		o.scratchDir = ""
+	}
+	return err
+}
+
+// track は検索・埋め込みの対象となる音楽ファイルの情報
+type track struct {
+	path       string
+	hasArtwork bool
+	query      provider.Query
+	run        *fileRun
+}
+
 // ProcessFile は単一の音楽ファイルを処理
-func (o *Orchestrator) ProcessFile(filePath string) error {
+// ctx がキャンセルされた場合は処理中のファイルを元に戻して中断する
+func (o *Orchestrator) ProcessFile(ctx context.Context, filePath string) error {
+	if err := o.setInputRoot(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	if err := o.recoverBeforeProcessing(ctx, o.recoveryTarget(filePath)); err != nil {
+		return err
+	}
+
 	fmt.Printf("処理中: %s\n", filePath)
 
-	// 元ファイルのバックアップを作成
-	backupPath := filePath + ".backup"
-	if err := fileutils.CreateBackup(filePath, backupPath); err != nil {
-		return fmt.Errorf("バックアップ作成エラー: %w", err)
+	t, err := o.inspectFile(ctx, filePath, os.Stdout)
+	if err != nil || t == nil {
+		return err
 	}
-	defer func() {
-		// 処理完了後、バックアップを削除（成功時のみ）
-		if _, err := os.Stat(backupPath); err == nil {
-			os.Remove(backupPath)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return o.processAlbum(ctx, []*track{t}, os.Stdout)
+}
+
+// ProcessDirectory はディレクトリ内の音楽ファイルを再帰的に処理
+// 同じフォルダ・同じアルバムの曲はまとめて1回だけ検索・ダウンロードする
+// 設定の並列数が2以上の場合、ファイルの確認とアルバムの処理をそれぞれ並列に行う
+// 進捗はジャーナルに記録し、再開モードでは前回までに処理済みのファイルをスキップする
+// ctx がキャンセルされた場合は新しいファイルの処理を始めず、処理中のファイルを完了または元に戻して中断する
+func (o *Orchestrator) ProcessDirectory(ctx context.Context, dirPath string) error {
+	if err := o.setInputRoot(dirPath); err != nil {
+		return err
+	}
+	if err := o.recoverBeforeProcessing(ctx, o.recoveryTarget(dirPath)); err != nil {
+		return err
+	}
+
+	if !o.config.DryRun {
+		// 出力先ディレクトリが指定されている場合は、元のディレクトリに書き込まないようジャーナルも出力先に置く
+		journalDir := dirPath
+		if o.config.OutputDir != "" {
+			if err := os.MkdirAll(o.config.OutputDir, 0755); err != nil {
+				return fmt.Errorf("出力先ディレクトリ作成エラー: %w", err)
+			}
+			journalDir = o.config.OutputDir
 		}
-	}()
+
+		j, err := journal.Open(journalDir, dirPath, o.config.Resume)
+		if err != nil {
+			return err
+		}
+		o.closeMu.Lock()
+		o.journal = j
+		o.closeMu.Unlock()
+	}
+
+	if err := o.processDirectory(ctx, dirPath); err != nil {
+		return err
+	}
+
+	// すべて成功した場合は再開の必要がないためジャーナルを削除
+	if o.journal != nil && o.tally.Summary().Failed == 0 {
+		if err := o.journal.Remove(); err != nil {
+			return fmt.Errorf("ジャーナル削除エラー: %w", err)
+		}
+	}
+	return nil
+}
+
+// processDirectory はディレクトリを走査し、フォルダごとにファイルを確認してアルバム単位で処理する
+// フォルダの確認が終わるたびにそのフォルダのアルバムを処理するため、走査の途中で中断しても処理済みのファイルはジャーナルに残る
+// 読み取れないフォルダは失敗として記録し、残りのフォルダの処理を続ける
+func (o *Orchestrator) processDirectory(ctx context.Context, dirPath string) error {
+	albumPool := newWorkerPool(o.config.Jobs)
+	err := fileutils.WalkMusicDirs(ctx, dirPath, func(dir string, files []string) error {
+		for _, tracks := range o.inspectFolder(ctx, files) {
+			tracks := tracks
+			albumPool.Submit(func(out io.Writer) {
+				if ctx.Err() != nil {
+					return
+				}
+				if len(tracks) == 1 {
+					fmt.Fprintf(out, "処理中: %s\n", tracks[0].path)
+				}
+				if err := o.processAlbum(ctx, tracks, out); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(out, "エラー: %v\n\n", err)
+				}
+			})
+		}
+		return ctx.Err()
+	}, func(dir string, err error) {
+		err = fmt.Errorf("フォルダを読み取れませんでした: %w", err)
+		stdoutMu.Lock()
+		fmt.Printf("エラー (%s): %v\n\n", dir, err)
+		stdoutMu.Unlock()
+		o.finish(newFileRun(dir), report.ActionFailed, "", err)
+	})
+	albumPool.Wait()
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// inspectFolder は同じフォルダの音楽ファイルを確認し、アルバム単位にまとめて返す
+func (o *Orchestrator) inspectFolder(ctx context.Context, files []string) [][]*track {
+	var mu sync.Mutex
+	var inspected []inspectedTrack
+
+	pool := newWorkerPool(o.config.Jobs)
+	for i, filePath := range files {
+		i, filePath := i, filePath
+		// 入力ディレクトリ内に出力先がある場合、作成したファイルは処理しない
+		if o.isOutputFile(filePath) {
+			continue
+		}
+
+		pool.Submit(func(out io.Writer) {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(out, "処理中: %s\n", filePath)
+
+			if o.config.Resume && o.journal != nil && o.journal.Completed(filePath) && o.outputExists(filePath) {
+				fmt.Fprintf(out, "  前回の実行で処理済みのためスキップします。\n\n")
+				o.finish(newFileRun(filePath), report.ActionSkipped, "前回の実行で処理済み", nil)
+				return
+			}
+
+			t, err := o.inspectFile(ctx, filePath, out)
+			if err != nil {
+				fmt.Fprintf(out, "エラー (%s): %v\n\n", filePath, err)
+				return
+			}
+			if t == nil {
+				return
+			}
+			fmt.Fprintln(out)
+
+			mu.Lock()
+			inspected = append(inspected, inspectedTrack{index: i, track: t})
+			mu.Unlock()
+		})
+	}
+	pool.Wait()
+
+	// ファイル名順に並べ直してからアルバム単位にまとめる
+	sort.Slice(inspected, func(i, j int) bool {
+		return inspected[i].index < inspected[j].index
+	})
+
+	var albums [][]*track
+	positions := make(map[string]int)
+	for _, it := range inspected {
+		key := albumKey(it.track)
+		pos, ok := positions[key]
+		if !ok {
+			pos = len(albums)
+			positions[key] = pos
+			albums = append(albums, nil)
+		}
+		albums[pos] = append(albums[pos], it.track)
+	}
+	return albums
+}
+
+// inspectedTrack は走査順を保持した確認済みの曲
+type inspectedTrack struct {
+	index int
+	track *track
+}
+
+// albumKey はアートワークを共有する単位（フォルダ + アルバムアーティスト + アルバム名）のキーを返す
+// アルバム名がない曲は1曲ずつ扱う
+// コンピレーションは曲ごとにアーティストが異なるため、アルバムアーティストのみで区別する
+func albumKey(t *track) string {
+	if t.query.Album == "" {
+		return t.path
+	}
+	return filepath.Dir(t.path) + "\x00" + strings.ToLower(t.query.AlbumArtist) + "\x00" + strings.ToLower(t.query.Album)
+}
+
+// inspectFile は既存アートワークとメタデータを確認し、検索条件を組み立てる
+// スキップする場合は nil を返す
+func (o *Orchestrator) inspectFile(ctx context.Context, filePath string, out io.Writer) (*track, error) {
+	run := newFileRun(filePath)
+
+	// レポート用にフォーマットを記録
+	if o.reporter != nil {
+		run.record.Format, _ = o.artworkProcessor.GetAudioFormat(ctx, filePath)
+	}
 
 	// 既存のアートワークをチェック
-	hasArtwork, err := o.artworkProcessor.HasExistingArtwork(filePath)
+	hasArtwork, err := o.artworkProcessor.HasExistingArtwork(ctx, filePath)
 	if err != nil {
-		fmt.Printf("  警告: アートワーク確認に失敗しました (%v)。処理を続行します。\n", err)
+		fmt.Fprintf(out, "  警告: アートワーク確認に失敗しました (%v)。処理を続行します。\n", err)
 	} else if hasArtwork && !o.config.ForceOverwrite {
-		fmt.Printf("  既存のアートワークが検出されました。スキップします。\n")
-		fmt.Printf("  強制上書きする場合は --force または -f オプションを使用してください。\n\n")
-		return nil
+		fmt.Fprintf(out, "  既存のアートワークが検出されました。スキップします。\n")
+		fmt.Fprintf(out, "  強制上書きする場合は --force または -f オプションを使用してください。\n\n")
+		o.skip(run, "既存のアートワークあり", nil)
+		return nil, nil
 	} else if hasArtwork && o.config.ForceOverwrite {
-		fmt.Printf("  既存のアートワークが検出されましたが、強制上書きモードで処理を続行します。\n")
+		fmt.Fprintf(out, "  既存のアートワークが検出されましたが、強制上書きモードで処理を続行します。\n")
 	}
 
 	// メタデータを抽出
-	artist, album, title, err := metadata.ExtractMetadata(filePath)
+	md, err := metadata.ExtractMetadata(filePath)
 	if err != nil {
-		return fmt.Errorf("メタデータ抽出エラー: %w", err)
+		err = fmt.Errorf("メタデータ抽出エラー: %w", err)
+		o.finish(run, report.ActionFailed, "", err)
+		return nil, err
+	}
+	// タグにない項目をファイル名パターン・フォルダ構成から補完
+	inferred := o.inferMissing(&md, filePath, out)
+	run.record.Artist = md.Artist
+	run.record.Album = md.Album
+	run.record.Title = md.Title
+	run.record.Inferred = strings.Join(inferred, ",")
+
+	// 推測した値には印を付けて表示
+	mark := func(field string) string {
+		for _, entry := range inferred {
+			if strings.HasPrefix(entry, field+":") {
+				return "（推測）"
+			}
+		}
+		return ""
+	}
+	fmt.Fprintf(out, "  アーティスト: %s%s\n", md.Artist, mark("artist"))
+	if md.AlbumArtist != "" && md.AlbumArtist != md.Artist {
+		fmt.Fprintf(out, "  アルバムアーティスト: %s%s\n", md.AlbumArtist, mark("albumartist"))
+	}
+	fmt.Fprintf(out, "  アルバム: %s%s\n", md.Album, mark("album"))
+	fmt.Fprintf(out, "  タイトル: %s%s\n", md.Title, mark("title"))
+	if md.ISRC != "" {
+		fmt.Fprintf(out, "  ISRC: %s\n", md.ISRC)
+	}
+	if md.Barcode != "" {
+		fmt.Fprintf(out, "  バーコード: %s\n", md.Barcode)
 	}
 
-	fmt.Printf("  アーティスト: %s\n", artist)
-	fmt.Printf("  アルバム: %s\n", album)
-	fmt.Printf("  タイトル: %s\n", title)
+	// 曲名がなければ検索できない
+	if md.Title == "" {
+		fmt.Fprintf(out, "  警告: タイトル情報とファイル名から曲名を抽出できませんでした。スキップします。\n\n")
+		o.skip(run, "曲名を特定できない", nil)
+		return nil, nil
+	}
 
-	// 検索に使用する情報を決定
-	searchArtist := artist
-	searchTitle := title
+	// アーティストが分からない場合は、架空の名前で検索条件を汚さないよう曲名・アルバム名のみで検索する
+	if md.Artist == "" {
+		fmt.Fprintf(out, "  警告: アーティスト情報がありません。アーティストを指定せずに検索します。\n")
+	}
 
-	// アーティスト情報が不足している場合のフォールバック
-	if artist == "" {
-		searchArtist = "Unknown Artist"
-		fmt.Printf("  警告: アーティスト情報がありません。'Unknown Artist' で検索します。\n")
+	// 候補の照合に使用する再生時間（取得できなければ0）
+	duration, err := fileutils.GetAudioDuration(ctx, filePath)
+	if err != nil {
+		duration = 0
 	}
 
-	// タイトル情報が不足している場合、ファイル名から抽出
-	if title == "" {
-		searchTitle = metadata.ExtractTitleFromFilename(filePath)
-		if searchTitle == "" {
-			fmt.Printf("  警告: タイトル情報とファイル名から曲名を抽出できませんでした。スキップします。\n\n")
-			return nil
+	query := provider.Query{
+		TrackMetadata: md,
+		FilePath:      filePath,
+		Duration:      duration,
+	}
+	run.record.Query = describeQuery(query)
+
+	return &track{
+		path:       filePath,
+		hasArtwork: hasArtwork,
+		query:      query,
+		run:        run,
+	}, nil
+}
+
+// inferMissing はタグにない項目をファイル名パターン、フォルダ構成の順に補完し、
+// 推測した項目を "項目名:推測元"（推測元は pattern または path）の形式で返す
+func (o *Orchestrator) inferMissing(md *metadata.TrackMetadata, filePath string, out io.Writer) []string {
+	if md.Artist != "" && md.Album != "" && md.Title != "" {
+		return nil
+	}
+
+	var inferred []string
+	fill := func(source string, from metadata.TrackMetadata) []string {
+		filled := md.FillMissing(from)
+		for _, field := range filled {
+			inferred = append(inferred, field+":"+source)
+		}
+		return filled
+	}
+
+	if fromName, pattern, ok := metadata.MatchFilename(o.filenamePatterns, filePath); ok {
+		if filled := fill("pattern", fromName); len(filled) > 0 {
+			fmt.Fprintf(out, "  ファイル名パターン '%s' から推測: %s\n", pattern, strings.Join(filled, ", "))
+		}
+	}
+
+	if md.Artist == "" || md.Album == "" || md.Title == "" {
+		if filled := fill("path", metadata.InferFromPath(filePath, o.inputRoot)); len(filled) > 0 {
+			fmt.Fprintf(out, "  フォルダ構成・ファイル名から推測: %s\n", strings.Join(filled, ", "))
 		}
-		fmt.Printf("  ファイル名から抽出した曲名で検索: %s\n", searchTitle)
 	}
+	return inferred
+}
 
-	// 最低限の情報（アーティストまたはタイトル）があるかチェック
-	if searchTitle == "" {
-		fmt.Printf("  警告: 検索に必要な情報が不足しています。スキップします。\n\n")
+// processAlbum はアートワークを1回だけ検索・ダウンロードし、全ての曲に埋め込む
+// 中断された曲は結果を記録しない（再開時に改めて処理する）
+func (o *Orchestrator) processAlbum(ctx context.Context, tracks []*track, out io.Writer) error {
+	if len(tracks) > 1 {
+		fmt.Fprintf(out, "アルバム: %s (%d曲)\n", tracks[0].query.Album, len(tracks))
+	}
+
+	if o.config.DryRun {
+		return o.planAlbum(ctx, tracks, out)
+	}
+
+	// 作業用ディレクトリ内に一意な名前の一時ファイルを作成
+	tempImage, err := os.CreateTemp(o.scratchDir, "artwork-*.img")
+	if err != nil {
+		err = fmt.Errorf("一時ファイル作成エラー: %w", err)
+		for _, t := range tracks {
+			o.finish(t.run, report.ActionFailed, "", err)
+		}
+		return err
+	}
+	tempImage.Close()
+	tempImagePath := tempImage.Name()
+	defer os.Remove(tempImagePath)
+
+	// プロバイダーを順に試してアートワークを検索・ダウンロード
+	candidate, err := o.resolveArtwork(ctx, tracks[0].query, tempImagePath, out)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		if !errors.Is(err, provider.ErrNotFound) {
+			fmt.Fprintf(out, "  エラー: アートワークを取得できませんでした (%v)\n\n", err)
+			for _, t := range tracks {
+				o.finish(t.run, report.ActionFailed, "", err)
+			}
+			// 1曲のみの場合はエラーをそのまま返す
+			if len(tracks) == 1 {
+				return err
+			}
+			return nil
+		}
+		fmt.Fprintf(out, "  警告: アートワークが見つかりませんでした (%v)。スキップします。\n\n", err)
+		for _, t := range tracks {
+			o.skip(t.run, "アートワークが見つからない", err)
+		}
 		return nil
 	}
 
-	// アートワークを検索
-	fmt.Println("  アートワークを検索中...")
-	artworkURL, err := o.spotifyClient.SearchArtwork(searchArtist, searchTitle)
+	// 1曲のみの場合はエラーをそのまま返す
+	if len(tracks) == 1 {
+		return o.embedAndRecord(ctx, tracks[0], candidate, tempImagePath, out)
+	}
+
+	for _, t := range tracks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fmt.Fprintf(out, "処理中: %s\n", t.path)
+		if err := o.embedAndRecord(ctx, t, candidate, tempImagePath, out); err != nil {
+			fmt.Fprintf(out, "エラー (%s): %v\n\n", t.path, err)
+		}
+	}
+
+	return nil
+}
+
+// embedAndRecord はアートワークを埋め込み、結果をレポートに記録する
+func (o *Orchestrator) embedAndRecord(ctx context.Context, t *track, candidate *provider.Candidate, imagePath string, out io.Writer) error {
+	t.run.setCandidate(candidate)
+
+	if err := o.embedArtwork(ctx, t, imagePath, out); err != nil {
+		// 中断された曲は元に戻したうえで記録しない（再開時に改めて処理する）
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		o.finish(t.run, report.ActionFailed, "", err)
+		return err
+	}
+
+	action := report.ActionEmbedded
+	if t.hasArtwork {
+		action = report.ActionReplaced
+	}
+	o.finish(t.run, action, "", nil)
+	return nil
+}
+
+// planAlbum はドライラン時に、検索結果と各ファイルに対して行う予定の処理を表示する
+func (o *Orchestrator) planAlbum(ctx context.Context, tracks []*track, out io.Writer) error {
+	candidate, err := o.resolveArtwork(ctx, tracks[0].query, "", out)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
-		fmt.Printf("  警告: アートワーク検索に失敗しました (%v)。スキップします。\n\n", err)
+		if !errors.Is(err, provider.ErrNotFound) {
+			fmt.Fprintf(out, "  [ドライラン] エラー: アートワークを取得できませんでした (%v)\n\n", err)
+			for _, t := range tracks {
+				o.finish(t.run, report.ActionFailed, "", err)
+			}
+			if len(tracks) == 1 {
+				return err
+			}
+			return nil
+		}
+		fmt.Fprintf(out, "  [ドライラン] アートワークが見つからないためスキップ予定 (%v)\n\n", err)
+		for _, t := range tracks {
+			o.skip(t.run, "アートワークが見つからない", err)
+		}
 		return nil
 	}
 
-	// 一時ファイルパスを生成
-	tempImagePath := filepath.Join(os.TempDir(), "temp_artwork.jpg")
-	defer os.Remove(tempImagePath)
+	image := candidate.ImageURL
+	if candidate.LocalPath != "" {
+		image = candidate.LocalPath
+	}
+	fmt.Fprintf(out, "  [ドライラン] 使用予定の画像: %s\n", image)
+	fmt.Fprintf(out, "    プロバイダー: %s, サイズ: %s, 一致度: %.2f\n", candidate.Provider, describeSize(*candidate), candidate.Score)
+
+	for _, t := range tracks {
+		action, reportAction := "埋め込み", report.ActionWouldEmbed
+		if t.hasArtwork {
+			action, reportAction = "置き換え", report.ActionWouldReplace
+		}
+		if o.config.OutputDir != "" {
+			destPath, _ := o.outputPath(t.path)
+			fmt.Fprintf(out, "  [ドライラン] %s予定: %s → %s\n", action, t.path, destPath)
+		} else {
+			fmt.Fprintf(out, "  [ドライラン] %s予定: %s\n", action, t.path)
+		}
+
+		t.run.setCandidate(candidate)
+		o.finish(t.run, reportAction, "", nil)
+	}
+	fmt.Fprintln(out)
+
+	return nil
+}
 
-	// 画像をダウンロード
-	fmt.Println("  アートワークをダウンロード中...")
-	if err := o.artworkProcessor.DownloadImage(artworkURL, tempImagePath); err != nil {
-		return fmt.Errorf("画像ダウンロードエラー: %w", err)
+// embedArtwork はダウンロード済みの画像を音楽ファイルに埋め込み、元ファイルを置き換える
+// 出力先ディレクトリが指定されている場合は元ファイルを変更せず、出力先に埋め込み済みのファイルを作成する
+// ctx がキャンセルされた場合はffmpeg・ffprobeを停止し、一時ファイルを削除してバックアップから元に戻す
+func (o *Orchestrator) embedArtwork(ctx context.Context, t *track, imagePath string, out io.Writer) error {
+	filePath := t.path
+
+	// 置き換え後のファイルに引き継ぐため、元ファイルの属性を取得
+	originalInfo, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	destPath := filePath
+	backupPath := ""
+	if o.config.OutputDir != "" {
+		// 出力先に書き込むため元ファイルのバックアップは不要
+		destPath, err = o.outputPath(filePath)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return fmt.Errorf("出力先ディレクトリ作成エラー: %w", err)
+		}
+	} else {
+		// 元ファイルのバックアップを作成（復旧されずに残ったバックアップは上書きしない）
+		backupPath = filePath + fileutils.BackupSuffix
+		if _, err := os.Stat(backupPath); err == nil {
+			return fmt.Errorf("前回の処理で残ったバックアップがあります: %s", backupPath)
+		}
+		if err := fileutils.CreateBackup(filePath, backupPath); err != nil {
+			return fmt.Errorf("バックアップ作成エラー: %w", err)
+		}
+		// バックアップから復元した場合も元の属性に戻るよう、バックアップにも属性を反映
+		if o.config.PreserveAttributes {
+			if err := fileutils.CopyAttributes(originalInfo, backupPath); err != nil {
+				fmt.Fprintf(out, "  警告: バックアップへの属性の反映に失敗しました (%v)\n", err)
+			}
+		}
+		defer func() {
+			// 処理完了後、バックアップを削除（成功時のみ）
+			if _, err := os.Stat(backupPath); err == nil {
+				os.Remove(backupPath)
+			}
+		}()
 	}
 
-	// 一時出力ファイルパスを生成（元ファイルを上書きするため）
-	tempOutputPath := filePath + ".tmp"
+	// 一時出力ファイルパスを生成（置き換えを1回のリネームで行うため）
+	tempOutputPath := destPath + fileutils.TempSuffix
+
+	// rollback は書きかけの出力を削除し、バックアップがあれば元ファイルを復元する
+	rollback := func() {
+		os.Remove(tempOutputPath)
+		if backupPath != "" {
+			restoreFromBackup(backupPath, filePath, out)
+		}
+	}
 
 	// アートワークを埋め込み
-	if hasArtwork && o.config.ForceOverwrite {
-		fmt.Println("  既存アートワークを置き換え中...")
-		if err := o.artworkProcessor.EmbedArtworkForceReplace(filePath, tempImagePath, tempOutputPath); err != nil {
-			// 失敗した場合、バックアップから復元
-			fileutils.RestoreFromBackup(backupPath, filePath)
+	if t.hasArtwork && o.config.ForceOverwrite {
+		fmt.Fprintln(out, "  既存アートワークを置き換え中...")
+		if err := o.artworkProcessor.EmbedArtworkForceReplace(ctx, filePath, imagePath, tempOutputPath, out); err != nil {
+			rollback()
 			return fmt.Errorf("アートワーク埋め込みエラー: %w", err)
 		}
 	} else {
-		fmt.Println("  アートワークを埋め込み中...")
-		if err := o.artworkProcessor.EmbedArtwork(filePath, tempImagePath, tempOutputPath); err != nil {
-			// 失敗した場合、バックアップから復元
-			fileutils.RestoreFromBackup(backupPath, filePath)
+		fmt.Fprintln(out, "  アートワークを埋め込み中...")
+		if err := o.artworkProcessor.EmbedArtwork(ctx, filePath, imagePath, tempOutputPath, out); err != nil {
+			rollback()
 			return fmt.Errorf("アートワーク埋め込みエラー: %w", err)
 		}
 	}
 
 	// 一時ファイルの整合性をチェック
-	if err := fileutils.ValidateAudioFile(tempOutputPath); err != nil {
-		os.Remove(tempOutputPath) // 破損ファイルを削除
-		fileutils.RestoreFromBackup(backupPath, filePath)
+	if err := fileutils.ValidateAudioFile(ctx, tempOutputPath); err != nil {
+		rollback()
 		return fmt.Errorf("出力ファイル検証エラー: %w", err)
 	}
 
-	// 元ファイルを一時ファイルで置き換え
-	if err := os.Rename(tempOutputPath, filePath); err != nil {
-		os.Remove(tempOutputPath) // クリーンアップ
-		fileutils.RestoreFromBackup(backupPath, filePath)
+	// 音声データが変更されていないことを確認（厳密な検証が有効な場合）
+	if o.config.VerifyAudio {
+		if err := verifyAudio(ctx, t, filePath, tempOutputPath, out); err != nil {
+			rollback()
+			return err
+		}
+	}
+
+	// 元ファイルのパーミッション・所有者・時刻を引き継ぐ
+	if o.config.PreserveAttributes {
+		if err := fileutils.CopyAttributes(originalInfo, tempOutputPath); err != nil {
+			rollback()
+			return fmt.Errorf("ファイル属性の反映エラー: %w", err)
+		}
+	}
+
+	// 一時ファイルを元ファイル（出力先）の位置に置く
+	if err := os.Rename(tempOutputPath, destPath); err != nil {
+		rollback()
 		return fmt.Errorf("ファイル置き換えエラー: %w", err)
 	}
 
-	fmt.Printf("  完了: %s\n\n", filePath)
+	t.run.record.Output = destPath
+	fmt.Fprintf(out, "  完了: %s\n\n", destPath)
 	return nil
 }
 
-// ProcessDirectory はディレクトリ内の音楽ファイルを再帰的に処理
-func (o *Orchestrator) ProcessDirectory(dirPath string) error {
-	return fileutils.ProcessDirectory(dirPath, o.ProcessFile)
+// verifyAudio は元ファイルと出力ファイルの音声パケットのハッシュを比較する
+func verifyAudio(ctx context.Context, t *track, originalPath, outputPath string, out io.Writer) error {
+	fmt.Fprintln(out, "  音声データを検証中...")
+
+	before, err := fileutils.AudioHash(ctx, originalPath)
+	if err != nil {
+		return fmt.Errorf("音声データ検証エラー（元ファイル）: %w", err)
+	}
+	after, err := fileutils.AudioHash(ctx, outputPath)
+	if err != nil {
+		return fmt.Errorf("音声データ検証エラー（出力ファイル）: %w", err)
+	}
+
+	if before != after {
+		return fmt.Errorf("音声データが変更されています（元: %s, 出力: %s）", before, after)
+	}
+
+	fmt.Fprintf(out, "    音声データは一致しました (MD5: %s)\n", after)
+	t.run.record.AudioMD5 = after
+	return nil
+}
+
+// restoreFromBackup はバックアップから元ファイルを復元
+func restoreFromBackup(backupPath, filePath string, out io.Writer) {
+	fmt.Fprintf(out, "  エラー検出: バックアップから復元中...\n")
+	if err := fileutils.RestoreFromBackup(backupPath, filePath); err != nil {
+		fmt.Fprintf(out, "  警告: バックアップからの復元に失敗しました (%v)\n", err)
+	}
 }
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"music-artwork-embedder/src/fileutils"
+	"music-artwork-embedder/src/report"
+)
+
+// setInputRoot は出力先ディレクトリに再現する入力ツリーの起点を設定する
+// 入力が出力先ディレクトリ自身またはその配下にある場合は、全てのファイルが出力先のファイルとして除外されるためエラーにする
+func (o *Orchestrator) setInputRoot(root string) error {
+	o.inputRoot = root
+	if o.config.OutputDir == "" {
+		return nil
+	}
+	inside, err := isWithin(root, o.config.OutputDir)
+	if err != nil {
+		return fmt.Errorf("出力先ディレクトリを確認できません: %w", err)
+	}
+	if inside {
+		return fmt.Errorf("出力先ディレクトリに入力と同じディレクトリ、または入力を含むディレクトリは指定できません: %s", o.config.OutputDir)
+	}
+	return nil
+}
+
+// outputPath は入力ファイルに対応する出力先ディレクトリ内のパスを返す
+func (o *Orchestrator) outputPath(filePath string) (string, error) {
+	rel, err := filepath.Rel(o.inputRoot, filePath)
+	if err != nil {
+		return "", fmt.Errorf("出力先のパスを決定できません: %w", err)
+	}
+	return filepath.Join(o.config.OutputDir, rel), nil
+}
+
+// outputExists は出力先ディレクトリに対応するファイルがあるかを返す（出力先の指定がなければ常にtrue）
+func (o *Orchestrator) outputExists(filePath string) bool {
+	if o.config.OutputDir == "" {
+		return true
+	}
+	destPath, err := o.outputPath(filePath)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(destPath)
+	return err == nil
+}
+
+// isOutputFile はファイルが出力先ディレクトリ内にあるか（入力ディレクトリ内に出力先がある場合の走査対象外）を返す
+func (o *Orchestrator) isOutputFile(filePath string) bool {
+	if o.config.OutputDir == "" {
+		return false
+	}
+	inside, err := isWithin(filePath, o.config.OutputDir)
+	return err == nil && inside
+}
+
+// skip はファイルをスキップとして記録する
+// 出力先ディレクトリが指定されている場合は、配布用のコピーが揃うよう元ファイルをそのままコピーする
+func (o *Orchestrator) skip(run *fileRun, reason string, cause error) {
+	if o.config.OutputDir != "" && !o.config.DryRun {
+		destPath, err := o.copyToOutput(run.record.Path)
+		if err != nil {
+			o.finish(run, report.ActionFailed, reason, fmt.Errorf("出力先へのコピーエラー: %w", err))
+			return
+		}
+		run.record.Output = destPath
+	}
+	o.finish(run, report.ActionSkipped, reason, cause)
+}
+
+// copyToOutput は元ファイルを変更せずに出力先ディレクトリへコピーし、コピー先のパスを返す
+func (o *Orchestrator) copyToOutput(filePath string) (string, error) {
+	destPath, err := o.outputPath(filePath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// 書きかけのファイルが出力先に残らないよう、一時ファイルに書いてから置き換える
+	tempPath := destPath + fileutils.TempSuffix
+	if err := fileutils.CopyFile(filePath, tempPath); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+	if o.config.PreserveAttributes {
+		if err := fileutils.CopyAttributes(info, tempPath); err != nil {
+			os.Remove(tempPath)
+			return "", err
+		}
+	}
+	if err := os.Rename(tempPath, destPath); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+	return destPath, nil
+}
+
+// isWithin は path が dir 自身またはその配下にあるかを返す
+func isWithin(path, dir string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
@@ -0,0 +1,180 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"music-artwork-embedder/src/artwork"
+	"music-artwork-embedder/src/config"
+	"music-artwork-embedder/src/fileutils"
+	"music-artwork-embedder/src/itunes"
+	"music-artwork-embedder/src/localart"
+	"music-artwork-embedder/src/musicbrainz"
+	"music-artwork-embedder/src/provider"
+	"music-artwork-embedder/src/spotify"
+)
+
+// newProviders は設定されたプロバイダー名から試行順のプロバイダー一覧を作成
+func newProviders(cfg *config.Config) ([]provider.ArtworkProvider, error) {
+	var providers []provider.ArtworkProvider
+	for _, name := range cfg.ArtworkProviders {
+		switch name {
+		case localart.ProviderName:
+			providers = append(providers, localart.NewProvider(cfg.LocalArtworkPatterns))
+		case spotify.ProviderName:
+			providers = append(providers, spotify.NewClient(cfg.SpotifyClientID, cfg.SpotifyClientSecret, spotify.Endpoints{
+				TokenURL:   cfg.SpotifyTokenURL,
+				APIBaseURL: cfg.SpotifyAPIBaseURL,
+			}, cfg.SpotifyRateLimit, cfg.HTTPTransport, cfg.Verbose))
+		case musicbrainz.ProviderName:
+			providers = append(providers, musicbrainz.NewClient(cfg.MusicBrainzBaseURL, cfg.CoverArtArchiveBaseURL, cfg.HTTPTransport))
+		case itunes.ProviderName:
+			providers = append(providers, itunes.NewClient(cfg.ITunesBaseURL, cfg.ITunesArtworkSize, cfg.HTTPTransport))
+		default:
+			return nil, fmt.Errorf("不明なアートワークプロバイダーです: %s", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("アートワークプロバイダーが設定されていません")
+	}
+
+	return providers, nil
+}
+
+// resolveArtwork はプロバイダーを設定順に試し、取得できた最初の候補の画像をimagePathに保存して返す
+// ドライランの場合は画像を取得せず、採用予定の候補を返す
+// どのプロバイダーでも取得できなかった場合、通信・ダウンロードのエラーがあればそのエラーを、
+// 全て該当なし（しきい値未満を含む）であれば provider.ErrNotFound をラップしたエラーを返す
+// ctx がキャンセルされた場合は残りのプロバイダー・候補を試さずにctxのエラーを返す
+func (o *Orchestrator) resolveArtwork(ctx context.Context, query provider.Query, imagePath string, out io.Writer) (*provider.Candidate, error) {
+	var lastErr error  // 最後の該当なしの理由
+	var fetchErr error // 最初に発生した通信・ダウンロードのエラー
+	for _, p := range o.providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(out, "  アートワークを検索中 (%s)...\n", p.Name())
+		candidates, err := p.Search(ctx, query, out)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Fprintf(out, "    %s: %v\n", p.Name(), err)
+			if errors.Is(err, provider.ErrNotFound) {
+				lastErr = err
+			} else if fetchErr == nil {
+				fetchErr = fmt.Errorf("%s: %w", p.Name(), err)
+			}
+			continue
+		}
+
+		// 一致度の高い順に並べ、しきい値未満の候補は採用しない
+		for _, candidate := range provider.Rank(query, candidates) {
+			if candidate.Score < o.config.MatchThreshold {
+				// 以降の候補も全てしきい値未満
+				fmt.Fprintf(out, "    一致度が低いため除外: %s (%.2f)\n", describeCandidate(candidate), candidate.Score)
+				lastErr = fmt.Errorf("一致度がしきい値 (%.2f) 以上の候補がありません: %w", o.config.MatchThreshold, provider.ErrNotFound)
+				break
+			}
+			fmt.Fprintf(out, "    候補: %s (一致度: %.2f)\n", describeCandidate(candidate), candidate.Score)
+
+			if candidate.LocalPath == "" && candidate.ImageURL == "" {
+				continue
+			}
+
+			if o.config.DryRun {
+				return &candidate, nil
+			}
+
+			// フォルダ内の画像はダウンロードせずにコピー
+			if candidate.LocalPath != "" {
+				if err := fileutils.CopyFile(candidate.LocalPath, imagePath); err != nil {
+					fmt.Fprintf(out, "    画像コピーエラー: %v\n", err)
+					if fetchErr == nil {
+						fetchErr = fmt.Errorf("画像コピーエラー: %w", err)
+					}
+					continue
+				}
+				return &candidate, nil
+			}
+
+			fmt.Fprintf(out, "  アートワークをダウンロード中 (%s)...\n", p.Name())
+			if err := o.artworkProcessor.DownloadImage(ctx, candidate.ImageURL, imagePath); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				fmt.Fprintf(out, "    画像ダウンロードエラー: %v\n", err)
+				if fetchErr == nil {
+					fetchErr = fmt.Errorf("画像ダウンロードエラー (%s): %w", p.Name(), err)
+				}
+				continue
+			}
+
+			// APIの検索結果にサイズがない場合は、ダウンロードした画像から実際のサイズを取得
+			if candidate.Width == 0 || candidate.Height == 0 {
+				if width, height, err := artwork.ImageSize(imagePath); err == nil {
+					candidate.Width, candidate.Height = width, height
+				}
+			}
+
+			return &candidate, nil
+		}
+	}
+
+	// 通信エラー等で確認できなかった候補がある場合は、該当なしとせず失敗として扱う（再開時に再試行する）
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	if lastErr == nil {
+		lastErr = provider.ErrNotFound
+	}
+	return nil, lastErr
+}
+
+// describeQuery は検索条件を表示用の文字列にする
+func describeQuery(q provider.Query) string {
+	var parts []string
+	for _, part := range []string{q.Artist, q.Album, q.Title} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if q.Barcode != "" {
+		parts = append(parts, "upc:"+q.Barcode)
+	}
+	if q.ISRC != "" {
+		parts = append(parts, "isrc:"+q.ISRC)
+	}
+	if q.MusicBrainz.ReleaseID != "" {
+		parts = append(parts, "mbid:"+q.MusicBrainz.ReleaseID)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// describeCandidate は候補を表示用の文字列にする
+func describeCandidate(c provider.Candidate) string {
+	if c.LocalPath != "" {
+		return c.LocalPath
+	}
+
+	var parts []string
+	for _, part := range []string{c.Artist, c.Album, c.Title} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+// describeSize は候補の画像サイズを表示用の文字列にする（ダウンロード前で不明な場合は「不明」）
+func describeSize(c provider.Candidate) string {
+	if c.Width == 0 || c.Height == 0 {
+		return "不明"
+	}
+	return fmt.Sprintf("%dx%d", c.Width, c.Height)
+}
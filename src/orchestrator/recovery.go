@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"music-artwork-embedder/src/fileutils"
+)
+
+// Recover はファイルまたはディレクトリ内に残ったバックアップ・一時ファイルを検証し、元ファイルの復元と削除を行う
+// ドライランの場合は復旧方法の表示のみ行う。復旧できなかったファイル数を返す
+func (o *Orchestrator) Recover(ctx context.Context, path string) (int, error) {
+	found, failed, err := o.recoverOrphans(ctx, path, os.Stdout)
+	if err == nil && found == 0 {
+		fmt.Println("残ったバックアップ・一時ファイルはありません")
+	}
+	return failed, err
+}
+
+// recoverOrphans は中断などで残ったファイルを復旧し、見つかった件数と復旧できなかった件数を返す
+func (o *Orchestrator) recoverOrphans(ctx context.Context, path string, out io.Writer) (int, int, error) {
+	orphans, err := fileutils.FindOrphans(ctx, path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("残ったファイルの検索エラー: %w", err)
+	}
+	if len(orphans) == 0 {
+		return 0, 0, nil
+	}
+
+	fmt.Fprintf(out, "前回の処理で残ったバックアップ・一時ファイルが %d 件見つかりました\n", len(orphans))
+
+	failed := 0
+	for _, orphan := range orphans {
+		recovery, err := fileutils.PlanRecovery(ctx, orphan)
+		if err != nil {
+			return len(orphans), failed, err
+		}
+
+		fmt.Fprintf(out, "  %s\n", orphan.Path)
+		if o.config.DryRun {
+			fmt.Fprintf(out, "    [ドライラン] %s\n", recovery.Describe())
+			continue
+		}
+
+		fmt.Fprintf(out, "    %s\n", recovery.Describe())
+		if err := recovery.Apply(); err != nil {
+			fmt.Fprintf(out, "    警告: %v\n", err)
+			failed++
+		}
+	}
+	fmt.Fprintln(out)
+
+	return len(orphans), failed, nil
+}
+
+// recoveryTarget は処理前に復旧を行う対象を返す
+// 出力先ディレクトリが指定されている場合、一時ファイルは出力先にしか作成しないため出力先を対象にする
+func (o *Orchestrator) recoveryTarget(path string) string {
+	if o.config.OutputDir == "" {
+		return path
+	}
+	if _, err := os.Stat(o.config.OutputDir); err != nil {
+		return ""
+	}
+	return o.config.OutputDir
+}
+
+// recoverBeforeProcessing は処理を始める前に、前回の処理で残ったファイルを復旧する
+func (o *Orchestrator) recoverBeforeProcessing(ctx context.Context, path string) error {
+	if path == "" {
+		return nil
+	}
+	_, failed, err := o.recoverOrphans(ctx, path, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		fmt.Printf("警告: %d 件のファイルを復旧できませんでした。`recover` サブコマンドで確認してください\n\n", failed)
+	}
+	return nil
+}
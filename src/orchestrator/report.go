@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"time"
+
+	"music-artwork-embedder/src/journal"
+	"music-artwork-embedder/src/provider"
+	"music-artwork-embedder/src/report"
+)
+
+// fileRun は1ファイル分の処理状況（レポート用の記録と開始時刻）
+type fileRun struct {
+	record  report.Record
+	started time.Time
+}
+
+// newFileRun はファイルの処理開始を記録
+func newFileRun(filePath string) *fileRun {
+	return &fileRun{
+		record:  report.Record{Path: filePath},
+		started: time.Now(),
+	}
+}
+
+// setCandidate は採用したアートワーク候補を記録
+func (r *fileRun) setCandidate(c *provider.Candidate) {
+	r.record.Provider = c.Provider
+	r.record.Candidate = describeCandidate(*c)
+	r.record.ImageURL = c.ImageURL
+	if c.LocalPath != "" {
+		r.record.ImageURL = c.LocalPath
+	}
+	r.record.ImageWidth = c.Width
+	r.record.ImageHeight = c.Height
+	r.record.Score = c.Score
+}
+
+// Summary はこれまでに処理したファイルの集計結果を返す
+func (o *Orchestrator) Summary() report.Summary {
+	return o.tally.Summary()
+}
+
+// finish はファイルの処理結果を確定し、レポートとジャーナルに書き出す
+func (o *Orchestrator) finish(run *fileRun, action, reason string, err error) {
+	run.record.Action = action
+	run.record.Reason = reason
+	if err != nil {
+		run.record.Error = err.Error()
+	}
+	run.record.DurationMs = time.Since(run.started).Milliseconds()
+
+	o.tally.Add(run.record)
+
+	if o.reporter != nil {
+		if err := o.reporter.Write(run.record); err != nil {
+			o.recordFailed(err)
+		}
+	}
+
+	if o.journal != nil {
+		if err := o.journal.Record(run.record.Path, journalStatus(action)); err != nil {
+			o.recordFailed(err)
+		}
+	}
+}
+
+// journalStatus は処理結果をジャーナルの状態に変換する
+// 処理済み（埋め込み・置き換え・既存アートワークや該当なしによるスキップ）のみを done とし、
+// 通信エラー等の失敗は再開時に再試行するよう failed とする
+func journalStatus(action string) string {
+	switch action {
+	case report.ActionEmbedded, report.ActionReplaced, report.ActionSkipped:
+		return journal.StatusDone
+	default:
+		return journal.StatusFailed
+	}
+}
+
+// recordFailed は最初に発生した書き込みエラーを保持する（書き込み失敗で処理自体は止めない）
+func (o *Orchestrator) recordFailed(err error) {
+	o.recordErrOnce.Do(func() {
+		o.recordErr = err
+	})
+}
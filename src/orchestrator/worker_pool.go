@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// stdoutMu は並列実行中の標準出力への書き出しを排他する（複数のワーカープールで共有）
+var stdoutMu sync.Mutex
+
+// workerPool は処理を指定した並列数で実行する
+// 並列実行時は処理ごとの出力をバッファし、完了時にまとめて標準出力へ書き出す
+type workerPool struct {
+	tasks chan func(io.Writer)
+	wg    sync.WaitGroup
+}
+
+// newWorkerPool は並列数を指定してワーカープールを作成（1以下は逐次実行）
+func newWorkerPool(jobs int) *workerPool {
+	p := &workerPool{}
+	if jobs <= 1 {
+		return p
+	}
+
+	p.tasks = make(chan func(io.Writer))
+	for i := 0; i < jobs; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				p.run(task)
+			}
+		}()
+	}
+	return p
+}
+
+// Submit は処理を追加（逐次実行の場合はその場で実行し、出力はバッファしない）
+func (p *workerPool) Submit(task func(out io.Writer)) {
+	if p.tasks == nil {
+		task(os.Stdout)
+		return
+	}
+	p.tasks <- task
+}
+
+// Wait は追加した全ての処理の完了を待つ
+func (p *workerPool) Wait() {
+	if p.tasks == nil {
+		return
+	}
+	close(p.tasks)
+	p.wg.Wait()
+}
+
+// run は処理の出力をバッファし、完了後に他の処理と混ざらないよう書き出す
+func (p *workerPool) run(task func(out io.Writer)) {
+	var buf bytes.Buffer
+	task(&buf)
+
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	os.Stdout.Write(buf.Bytes())
+}
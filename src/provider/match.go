@@ -0,0 +1,226 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 各項目の一致度にかける重み
+const (
+	titleWeight    = 0.4
+	artistWeight   = 0.3
+	albumWeight    = 0.3
+	durationWeight = 0.2
+)
+
+// 再生時間の差がこの範囲内なら一致、maxDurationDiff以上なら不一致とみなす
+const (
+	durationTolerance = 3 * time.Second
+	maxDurationDiff   = 30 * time.Second
+)
+
+// versionKeywords は別バージョンを示す語。検索条件の曲名に含まれない場合は減点する
+// 英語の語は単語単位で比較する（"Discovery" の "cover" や "Alive" の "live" には一致させない）
+var versionKeywords = []string{
+	"karaoke", "カラオケ", "instrumental", "inst", "off vocal", "remix", "live", "acoustic", "cover",
+}
+
+// versionPenalty は条件にない別バージョンの候補にかける係数
+const versionPenalty = 0.5
+
+// Rank は候補ごとの一致度を計算し、一致度の高い順に並べ替えて返す
+func Rank(query Query, candidates []Candidate) []Candidate {
+	ranked := make([]Candidate, len(candidates))
+	copy(ranked, candidates)
+
+	for i := range ranked {
+		if ranked[i].Exact {
+			ranked[i].Score = 1
+			continue
+		}
+		ranked[i].Score = Score(query, ranked[i])
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// Score は検索条件と候補の一致度を0〜1で返す
+// 双方に値がある項目のみで重み付き平均を取る
+// アーティスト・アルバム名のどちらも比較できない場合は、曲名が同じ別の曲（カバー等）と区別できないため0を返す
+func Score(query Query, c Candidate) float64 {
+	var total, weights float64
+	identified := false
+	add := func(weight, similarity float64) {
+		total += weight * similarity
+		weights += weight
+	}
+
+	if query.Title != "" && c.Title != "" {
+		add(titleWeight, Similarity(query.Title, c.Title))
+	}
+	if query.Artist != "" && c.Artist != "" {
+		similarity := artistSimilarity(query.Artist, c.Artist)
+		// コンピレーションのアルバム候補はアルバムアーティスト名で返るため、そちらとの一致も考慮する
+		if query.AlbumArtist != "" {
+			if sim := artistSimilarity(query.AlbumArtist, c.Artist); sim > similarity {
+				similarity = sim
+			}
+		}
+		add(artistWeight, similarity)
+		identified = true
+	}
+	if query.Album != "" && c.Album != "" {
+		add(albumWeight, Similarity(query.Album, c.Album))
+		identified = true
+	}
+	if query.Duration > 0 && c.Duration > 0 {
+		add(durationWeight, durationSimilarity(query.Duration, c.Duration))
+	}
+
+	if !identified {
+		return 0
+	}
+
+	score := total / weights
+	if isOtherVersion(query, c) {
+		score *= versionPenalty
+	}
+	return score
+}
+
+// Similarity は正規化した文字列同士の編集距離から類似度を0〜1で返す
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(normalize(a)), []rune(normalize(b))
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+// artistSimilarity は候補のアーティスト一覧（カンマ区切り）全体と各アーティストの最大類似度を返す
+func artistSimilarity(queryArtist, candidateArtists string) float64 {
+	best := Similarity(queryArtist, candidateArtists)
+	for _, artist := range strings.Split(candidateArtists, ",") {
+		if sim := Similarity(queryArtist, artist); sim > best {
+			best = sim
+		}
+	}
+	return best
+}
+
+// durationSimilarity は再生時間の差から類似度を0〜1で返す
+func durationSimilarity(a, b time.Duration) float64 {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+
+	switch {
+	case diff <= durationTolerance:
+		return 1
+	case diff >= maxDurationDiff:
+		return 0
+	default:
+		return 1 - float64(diff-durationTolerance)/float64(maxDurationDiff-durationTolerance)
+	}
+}
+
+// isOtherVersion は候補の曲名が検索条件にない別バージョン（カラオケ・リミックス等）かを判定
+// アルバム名は比較しない（曲名が一致していればライブ盤等の収録でも同じ曲とみなす）
+func isOtherVersion(query Query, c Candidate) bool {
+	queryWords := words(query.Title)
+	candidateWords := words(c.Title)
+
+	for _, keyword := range versionKeywords {
+		if containsKeyword(candidateWords, keyword) && !containsKeyword(queryWords, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// words は小文字化した文字列を文字と数字の連続（単語）に分割する
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsKeyword は単語列に keyword（複数語の場合は連続した単語）が含まれるかを返す
+// 単語の区切りがない日本語の keyword は単語内の部分一致で判定する
+func containsKeyword(ws []string, keyword string) bool {
+	kws := words(keyword)
+	if len(kws) == 0 {
+		return false
+	}
+	if !isASCII(keyword) {
+		return strings.Contains(strings.Join(ws, " "), strings.Join(kws, " "))
+	}
+
+	for i := 0; i+len(kws) <= len(ws); i++ {
+		matched := true
+		for j, kw := range kws {
+			if ws[i+j] != kw {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isASCII は文字列がASCII文字のみからなるかを返す
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize は比較用に小文字化し、文字と数字以外を取り除く
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// levenshtein はルーン単位の編集距離を計算
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
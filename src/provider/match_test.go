@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"music-artwork-embedder/src/metadata"
+)
+
+func newQuery(artist, album, title string, duration time.Duration) Query {
+	return Query{
+		TrackMetadata: metadata.TrackMetadata{Artist: artist, Album: album, Title: title},
+		Duration:      duration,
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     Query
+		candidate Candidate
+		wantMin   float64
+		wantMax   float64
+	}{
+		{
+			name:      "アルバム名に cover を含む語（Discovery）",
+			query:     newQuery("Daft Punk", "Discovery", "One More Time", 320*time.Second),
+			candidate: Candidate{Artist: "Daft Punk", Album: "Discovery", Title: "One More Time", Duration: 320 * time.Second},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "アルバム名に cover を含む語（Recovery）",
+			query:     newQuery("Eminem", "Recovery", "Not Afraid", 248*time.Second),
+			candidate: Candidate{Artist: "Eminem", Album: "Recovery", Title: "Not Afraid", Duration: 248 * time.Second},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "曲名に live を含む語（Alive）",
+			query:     newQuery("Pearl Jam", "Ten", "Alive", 341*time.Second),
+			candidate: Candidate{Artist: "Pearl Jam", Album: "Ten", Title: "Alive", Duration: 341 * time.Second},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "ライブアルバムの収録曲は減点しない",
+			query:     newQuery("Queen", "", "Bohemian Rhapsody", 0),
+			candidate: Candidate{Artist: "Queen", Album: "Live Killers", Title: "Bohemian Rhapsody"},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "曲名が Live 版の候補は減点",
+			query:     newQuery("Queen", "", "Bohemian Rhapsody", 0),
+			candidate: Candidate{Artist: "Queen", Title: "Bohemian Rhapsody - Live"},
+			wantMin:   0,
+			wantMax:   0.5,
+		},
+		{
+			name:      "検索条件にも含まれる語は減点しない",
+			query:     newQuery("Artist", "", "Song (Remix)", 0),
+			candidate: Candidate{Artist: "Artist", Title: "Song (Remix)"},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "複数語のキーワード",
+			query:     newQuery("Artist", "", "Song", 0),
+			candidate: Candidate{Artist: "Artist", Title: "Song (Off Vocal)"},
+			wantMin:   0,
+			wantMax:   0.5,
+		},
+		{
+			name:      "日本語のキーワード",
+			query:     newQuery("アーティスト", "", "曲名", 0),
+			candidate: Candidate{Artist: "アーティスト", Title: "曲名（オリジナルカラオケ）"},
+			wantMin:   0,
+			wantMax:   0.5,
+		},
+		{
+			name:      "コンピレーションはアルバムアーティストとも照合",
+			query:     Query{TrackMetadata: metadata.TrackMetadata{Artist: "Track Artist", AlbumArtist: "Various Artists", Album: "Hits"}},
+			candidate: Candidate{Artist: "Various Artists", Album: "Hits"},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "曲名のみの検索条件では同名の別の曲と区別できない",
+			query:     newQuery("", "", "Yesterday", 0),
+			candidate: Candidate{Artist: "Random Cover Band", Album: "Pub Hits", Title: "Yesterday"},
+			wantMin:   0,
+			wantMax:   0,
+		},
+		{
+			name:      "曲名と再生時間のみの検索条件",
+			query:     newQuery("", "", "Yesterday", 125*time.Second),
+			candidate: Candidate{Artist: "Random Cover Band", Album: "Pub Hits", Title: "Yesterday", Duration: 125 * time.Second},
+			wantMin:   0,
+			wantMax:   0,
+		},
+		{
+			name:      "アーティスト不明でもアルバム名で照合できる",
+			query:     newQuery("", "Help!", "Yesterday", 0),
+			candidate: Candidate{Artist: "The Beatles", Album: "Help!", Title: "Yesterday"},
+			wantMin:   0.99,
+			wantMax:   1,
+		},
+		{
+			name:      "比較できる項目がない",
+			query:     newQuery("", "", "Song", 0),
+			candidate: Candidate{Artist: "Artist"},
+			wantMin:   0,
+			wantMax:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.query, tt.candidate)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("Score() = %.3f, want %.2f〜%.2f", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      Query
+		candidates []Candidate
+		wantOrder  []string // 並べ替え後の候補のアルバム名
+	}{
+		{
+			name:  "一致度の高い順",
+			query: newQuery("Daft Punk", "Discovery", "One More Time", 0),
+			candidates: []Candidate{
+				{Artist: "Someone Else", Album: "Other", Title: "Another Song"},
+				{Artist: "Daft Punk", Album: "Discovery", Title: "One More Time"},
+				{Artist: "Daft Punk", Album: "Alive 2007", Title: "One More Time / Aerodynamic"},
+			},
+			wantOrder: []string{"Discovery", "Alive 2007", "Other"},
+		},
+		{
+			name:  "別バージョンの曲は後ろに並ぶ",
+			query: newQuery("Artist", "", "Song", 0),
+			candidates: []Candidate{
+				{Artist: "Artist", Album: "Karaoke", Title: "Song (Karaoke Version)"},
+				{Artist: "Artist", Album: "Original", Title: "Song"},
+			},
+			wantOrder: []string{"Original", "Karaoke"},
+		},
+		{
+			name:  "確実な一致は一致度1で元の順序を保つ",
+			query: newQuery("Artist", "Album", "Song", 0),
+			candidates: []Candidate{
+				{Artist: "Artist", Album: "Album (Deluxe)", Title: "Song"},
+				{Album: "Exact A", Exact: true},
+				{Album: "Exact B", Exact: true},
+			},
+			wantOrder: []string{"Exact A", "Exact B", "Album (Deluxe)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := Rank(tt.query, tt.candidates)
+			if len(ranked) != len(tt.wantOrder) {
+				t.Fatalf("Rank() returned %d candidates, want %d", len(ranked), len(tt.wantOrder))
+			}
+			for i, want := range tt.wantOrder {
+				if ranked[i].Album != want {
+					t.Errorf("Rank()[%d].Album = %q (score %.3f), want %q", i, ranked[i].Album, ranked[i].Score, want)
+				}
+			}
+			for _, c := range ranked {
+				if c.Exact && c.Score != 1 {
+					t.Errorf("exact candidate %q has score %.3f, want 1", c.Album, c.Score)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"music-artwork-embedder/src/metadata"
+)
+
+// ErrNotFound は条件に一致するアートワークがないことを示す
+// 各プロバイダーは該当なしの場合にこのエラー（またはこれをラップしたエラー）を返し、通信エラー等と区別する
+var ErrNotFound = errors.New("アートワークが見つかりませんでした")
+
+// Query はアートワーク検索の条件
+// Artist・Album・Title は検索に使う値（タグになければファイル名などから補完したもの）
+type Query struct {
+	metadata.TrackMetadata
+
+	FilePath string        // 処理対象の音楽ファイルのパス
+	Duration time.Duration // 音楽ファイルの再生時間（不明な場合は0）
+}
+
+// Candidate はプロバイダーが返すアートワーク候補
+type Candidate struct {
+	Provider  string // 候補を返したプロバイダー名
+	ImageURL  string
+	LocalPath string // ローカルの画像ファイル（ダウンロード不要な場合に設定）
+	Width     int    // 画像の幅（不明な場合は0）
+	Height    int    // 画像の高さ（不明な場合は0）
+	Exact     bool   // 対象ファイルに確実に対応する候補か
+
+	// 候補の元になった楽曲・アルバムの情報
+	Artist   string
+	Album    string
+	Title    string
+	Duration time.Duration // 曲の再生時間（不明な場合は0）
+
+	Score float64 // 検索条件との一致度（0〜1、Rankで設定）
+}
+
+// ArtworkProvider はアートワーク検索サービスの共通インターフェース
+type ArtworkProvider interface {
+	// Name は設定で指定するプロバイダー名を返す
+	Name() string
+	// Search は条件に一致するアートワーク候補を優先度の高い順に返す
+	// 進捗やデバッグ情報は out に出力する（並列処理時に他のファイルの出力と混ざらないよう）
+	// ctx がキャンセルされた場合は通信を中止してエラーを返す
+	Search(ctx context.Context, query Query, out io.Writer) ([]Candidate, error)
+}
+
+// Initializer は検索前に初期化（認証など）が必要なプロバイダーが実装する
+type Initializer interface {
+	Initialize(ctx context.Context) error
+}
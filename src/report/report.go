@@ -0,0 +1,135 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ファイルごとに行われた処理の種類
+const (
+	ActionEmbedded     = "embedded"      // アートワークを埋め込んだ
+	ActionReplaced     = "replaced"      // 既存アートワークを置き換えた
+	ActionSkipped      = "skipped"       // 処理しなかった（理由はReasonに記録）
+	ActionFailed       = "failed"        // エラーで失敗した
+	ActionWouldEmbed   = "would-embed"   // ドライラン: 埋め込み予定
+	ActionWouldReplace = "would-replace" // ドライラン: 置き換え予定
+)
+
+// Record は1ファイル分の処理結果
+type Record struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+
+	// タグから読み取った情報（タグにない項目はファイル名・フォルダ構成から推測した値）
+	Artist   string `json:"artist"`
+	Album    string `json:"album"`
+	Title    string `json:"title"`
+	Inferred string `json:"inferred,omitempty"` // 推測した項目（"artist:path" のように 項目名:推測元 をカンマ区切り）
+
+	Query string `json:"query"` // 検索に使用した条件
+
+	// 採用したアートワーク候補
+	Provider    string  `json:"provider"`
+	Candidate   string  `json:"candidate"`
+	ImageURL    string  `json:"image_url"`
+	ImageWidth  int     `json:"image_width"`
+	ImageHeight int     `json:"image_height"`
+	Score       float64 `json:"score"`
+
+	Output   string `json:"output,omitempty"`    // 出力先ディレクトリに作成したファイル（--output-dir）
+	AudioMD5 string `json:"audio_md5,omitempty"` // 埋め込み前後で一致を確認した音声データのハッシュ（--verify-audio）
+
+	Action     string `json:"action"`
+	Reason     string `json:"reason"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error"`
+}
+
+// csvHeader はCSV形式の列名
+var csvHeader = []string{
+	"path", "format", "artist", "album", "title", "inferred", "query",
+	"provider", "candidate", "image_url", "image_width", "image_height", "score", "output", "audio_md5",
+	"action", "reason", "duration_ms", "error",
+}
+
+// csvRow はCSV形式の1行に変換
+func (r Record) csvRow() []string {
+	return []string{
+		r.Path, r.Format, r.Artist, r.Album, r.Title, r.Inferred, r.Query,
+		r.Provider, r.Candidate, r.ImageURL, strconv.Itoa(r.ImageWidth), strconv.Itoa(r.ImageHeight),
+		strconv.FormatFloat(r.Score, 'f', 2, 64), r.Output, r.AudioMD5,
+		r.Action, r.Reason, strconv.FormatInt(r.DurationMs, 10), r.Error,
+	}
+}
+
+// Writer は処理結果をファイルに書き出す（複数のゴルーチンから安全に呼び出せる）
+type Writer struct {
+	mu    sync.Mutex
+	file  *os.File
+	write func(Record) error
+	flush func() error
+}
+
+// Open は拡張子（.jsonl / .csv）に応じた形式でレポートファイルを作成
+func Open(path string) (*Writer, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".jsonl" && ext != ".csv" {
+		return nil, fmt.Errorf("レポートの拡張子は .jsonl または .csv を指定してください: %s", path)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{file: file}
+	if ext == ".csv" {
+		cw := csv.NewWriter(file)
+		if err := cw.Write(csvHeader); err != nil {
+			file.Close()
+			return nil, err
+		}
+		w.write = func(r Record) error { return cw.Write(r.csvRow()) }
+		w.flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	} else {
+		encoder := json.NewEncoder(file)
+		encoder.SetEscapeHTML(false)
+		w.write = func(r Record) error { return encoder.Encode(r) }
+		w.flush = func() error { return nil }
+	}
+
+	return w, nil
+}
+
+// Write は1ファイル分の処理結果を書き出す
+func (w *Writer) Write(r Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.write(r); err != nil {
+		return err
+	}
+	// 中断された場合でもそれまでの結果が残るよう、1件ごとに書き出す
+	return w.flush()
+}
+
+// Close はレポートファイルを閉じる
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
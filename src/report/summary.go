@@ -0,0 +1,83 @@
+package report
+
+import (
+	"sort"
+	"sync"
+)
+
+// Summary は処理結果の集計
+type Summary struct {
+	Processed int
+	Embedded  int // ドライラン時は埋め込み予定の数
+	Replaced  int // ドライラン時は置き換え予定の数
+	Skipped   int
+	Failed    int
+
+	SkipReasons map[string]int // スキップ理由ごとの件数
+}
+
+// SkipReason はスキップ理由とその件数
+type SkipReason struct {
+	Reason string
+	Count  int
+}
+
+// Add は処理結果を集計に加える
+func (s *Summary) Add(r Record) {
+	s.Processed++
+	switch r.Action {
+	case ActionEmbedded, ActionWouldEmbed:
+		s.Embedded++
+	case ActionReplaced, ActionWouldReplace:
+		s.Replaced++
+	case ActionSkipped:
+		s.Skipped++
+		if s.SkipReasons == nil {
+			s.SkipReasons = make(map[string]int)
+		}
+		s.SkipReasons[r.Reason]++
+	case ActionFailed:
+		s.Failed++
+	}
+}
+
+// SortedSkipReasons はスキップ理由を件数の多い順に返す
+func (s Summary) SortedSkipReasons() []SkipReason {
+	reasons := make([]SkipReason, 0, len(s.SkipReasons))
+	for reason, count := range s.SkipReasons {
+		reasons = append(reasons, SkipReason{Reason: reason, Count: count})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Count != reasons[j].Count {
+			return reasons[i].Count > reasons[j].Count
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	return reasons
+}
+
+// Tally は並列処理中の結果を安全に集計する
+type Tally struct {
+	mu      sync.Mutex
+	summary Summary
+}
+
+// Add は処理結果を集計に加える
+func (t *Tally) Add(r Record) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.summary.Add(r)
+}
+
+// Summary は現時点の集計結果を返す
+func (t *Tally) Summary() Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := t.summary
+	summary.SkipReasons = make(map[string]int, len(t.summary.SkipReasons))
+	for reason, count := range t.summary.SkipReasons {
+		summary.SkipReasons[reason] = count
+	}
+	return summary
+}
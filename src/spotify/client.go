@@ -1,137 +1,420 @@
 package spotify
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"music-artwork-embedder/src/provider"
+)
+
+// ProviderName は設定で使用するSpotifyプロバイダー名
+const ProviderName = "spotify"
+
+const (
+	// DefaultTokenURL はSpotifyトークンAPIのデフォルトURL
+	DefaultTokenURL = "https://accounts.spotify.com/api/token"
+	// DefaultAPIBaseURL はSpotify Web APIのデフォルトURL
+	DefaultAPIBaseURL = "https://api.spotify.com/v1"
 )
 
+// Endpoints はSpotify APIのURL（空の項目はデフォルトを使用）
+type Endpoints struct {
+	TokenURL   string
+	APIBaseURL string
+}
+
+// searchLimit は1回の検索で取得する候補数（一致度で順位付けするため複数取得）
+const searchLimit = 10
+
 // Client はSpotify APIクライアント
 type Client struct {
+	clientID     string
+	clientSecret string
+	endpoints    Endpoints
+	httpClient   *http.Client
+	limiter      *rateLimiter
+	verbose      bool // 検索の経過やレスポンスボディを表示する
+
+	tokenMu     sync.Mutex
 	accessToken string
-	httpClient  *http.Client
+	tokenExpiry time.Time // アクセストークンの有効期限
 }
 
 // NewClient は新しいSpotifyクライアントを作成
-func NewClient() *Client {
+// requestsPerSecond は検索APIへの1秒あたりのリクエスト数の上限（0以下は無制限）
+// transport がnilの場合は http.DefaultTransport を使用
+// verbose が true の場合は検索の経過（クエリ・レスポンスボディ・候補の画像など）を表示する
+func NewClient(clientID, clientSecret string, endpoints Endpoints, requestsPerSecond float64, transport http.RoundTripper, verbose bool) *Client {
+	if endpoints.TokenURL == "" {
+		endpoints.TokenURL = DefaultTokenURL
+	}
+	if endpoints.APIBaseURL == "" {
+		endpoints.APIBaseURL = DefaultAPIBaseURL
+	}
+	endpoints.APIBaseURL = strings.TrimRight(endpoints.APIBaseURL, "/")
+
 	return &Client{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		endpoints:    endpoints,
+		httpClient:   &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		limiter:      newRateLimiter(requestsPerSecond),
+		verbose:      verbose,
 	}
 }
 
-// GetToken はSpotify Web APIのアクセストークンを取得
-func (c *Client) GetToken(clientID, clientSecret string) error {
-	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
+// debugf は詳細表示が有効な場合のみ、検索の経過を出力する
+func (c *Client) debugf(out io.Writer, format string, args ...interface{}) {
+	if c.verbose {
+		fmt.Fprintf(out, "Debug: "+format, args...)
+	}
+}
 
-	req, err := http.NewRequest("POST", "https://accounts.spotify.com/api/token", strings.NewReader(data.Encode()))
-	if err != nil {
-		return err
+// Name はプロバイダー名を返す
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// Initialize は保持している認証情報でアクセストークンを取得
+func (c *Client) Initialize(ctx context.Context) error {
+	fmt.Println("Spotify API認証中...")
+	if err := c.GetToken(ctx, c.clientID, c.clientSecret); err != nil {
+		return fmt.Errorf("Spotify認証エラー: %w", err)
 	}
+	return nil
+}
 
-	req.SetBasicAuth(clientID, clientSecret)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+// Search はSpotify APIを使用してアートワーク候補を検索
+// タグにバーコード・ISRCがあれば識別子で検索し、見つかった候補を確実な一致として返す
+// 識別子があるのに見つからない場合は、別のリリースを誤って採用しないようテキスト検索を行わず ErrNotFound を返す
+// 識別子がない場合、アルバム名があればアルバム検索を行い、見つからなければ曲検索にフォールバック
+func (c *Client) Search(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	c.debugf(out, "アートワーク検索開始\n")
+	c.debugf(out, "アーティスト: '%s'\n", query.Artist)
+	if query.AlbumArtist != "" {
+		c.debugf(out, "アルバムアーティスト: '%s'\n", query.AlbumArtist)
+	}
+	c.debugf(out, "アルバム: '%s'\n", query.Album)
+	c.debugf(out, "曲名: '%s'\n", query.Title)
 
-	resp, err := c.httpClient.Do(req)
+	if query.Barcode != "" {
+		candidates, err := c.searchByUPC(ctx, query, out)
+		if err == nil {
+			return candidates, nil
+		}
+		// 該当なし以外（通信エラー等）は他の方法で検索せずにエラーを返す
+		if !errors.Is(err, provider.ErrNotFound) {
+			return nil, err
+		}
+		c.debugf(out, "バーコードで見つかりませんでした (%v)\n", err)
+	}
+
+	if query.ISRC != "" {
+		candidates, err := c.searchByISRC(ctx, query, out)
+		if err == nil {
+			return candidates, nil
+		}
+		if !errors.Is(err, provider.ErrNotFound) {
+			return nil, err
+		}
+		c.debugf(out, "ISRCで見つかりませんでした (%v)\n", err)
+	}
+
+	if query.Barcode != "" || query.ISRC != "" {
+		return nil, fmt.Errorf("バーコード・ISRCに一致する結果がありません: %w", provider.ErrNotFound)
+	}
+
+	if query.Album != "" {
+		candidates, err := c.searchAlbums(ctx, query, out)
+		if err == nil {
+			return candidates, nil
+		}
+		if !errors.Is(err, provider.ErrNotFound) {
+			return nil, err
+		}
+		c.debugf(out, "アルバム検索で見つからなかったため曲検索を行います (%v)\n", err)
+	}
+
+	return c.searchTracks(ctx, query, out)
+}
+
+// searchByUPC はアルバムのバーコード（UPC/EAN）でアルバムを検索し、確実な一致として返す
+func (c *Client) searchByUPC(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	searchResp, err := c.search(ctx, "upc:"+query.Barcode, "album", out)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var tokenResp struct {
-		AccessToken string `json:"access_token"`
+	c.debugf(out, "バーコード検索結果アルバム数: %d\n", len(searchResp.Albums.Items))
+
+	var candidates []provider.Candidate
+	for _, album := range searchResp.Albums.Items {
+		candidate, ok := c.albumCandidate(album, out)
+		if !ok {
+			continue
+		}
+		candidate.Exact = true
+		candidates = append(candidates, candidate)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return err
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("バーコード %s に一致するアルバムがありません: %w", query.Barcode, provider.ErrNotFound)
 	}
 
-	c.accessToken = tokenResp.AccessToken
-	return nil
+	return candidates, nil
 }
 
-// SearchArtwork はSpotify APIを使用してアートワークを検索（曲検索ベース）
-func (c *Client) SearchArtwork(artist, title string) (string, error) {
-	fmt.Printf("Debug: アートワーク検索開始\n")
-	fmt.Printf("Debug: アーティスト: '%s'\n", artist)
-	fmt.Printf("Debug: 曲名: '%s'\n", title)
+// searchByISRC はISRCで曲を検索し、収録アルバムの画像を確実な一致として返す
+// 同じ録音が複数のアルバム（オリジナル盤・ベスト盤など）に収録されている場合があるため、
+// タグのアルバム名に近いものから順に返す
+func (c *Client) searchByISRC(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	searchResp, err := c.search(ctx, "isrc:"+query.ISRC, "track", out)
+	if err != nil {
+		return nil, err
+	}
 
-	// 曲名とアーティスト名で検索
-	query := fmt.Sprintf("track:%s artist:%s", title, artist)
-	encodedQuery := url.QueryEscape(query)
+	c.debugf(out, "ISRC検索結果楽曲数: %d\n", len(searchResp.Tracks.Items))
 
-	fmt.Printf("Debug: 検索クエリ: '%s'\n", query)
-	fmt.Printf("Debug: エンコード済みクエリ: '%s'\n", encodedQuery)
+	var candidates []provider.Candidate
+	for _, track := range searchResp.Tracks.Items {
+		candidate, ok := c.albumCandidate(track.Album, out)
+		if !ok {
+			continue
+		}
+		candidate.Artist = joinArtists(track.Artists)
+		candidate.Title = track.Name
+		candidate.Duration = time.Duration(track.DurationMs) * time.Millisecond
+		candidate.Exact = true
+		candidates = append(candidates, candidate)
+	}
 
-	searchURL := fmt.Sprintf("https://api.spotify.com/v1/search?q=%s&type=track&limit=1", encodedQuery)
-	fmt.Printf("Debug: 検索URL: %s\n", searchURL)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("ISRC %s に一致する曲がありません: %w", query.ISRC, provider.ErrNotFound)
+	}
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+	if query.Album != "" {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return provider.Similarity(query.Album, candidates[i].Album) > provider.Similarity(query.Album, candidates[j].Album)
+		})
+	}
+
+	return candidates, nil
+}
+
+// searchAlbums はアルバム名とアーティスト名（アルバムアーティストを優先）でアルバムを検索
+func (c *Client) searchAlbums(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	searchQuery := fmt.Sprintf("album:%s", query.Album)
+	if artist := query.AlbumSearchArtist(); artist != "" {
+		searchQuery += fmt.Sprintf(" artist:%s", artist)
+	}
+
+	searchResp, err := c.search(ctx, searchQuery, "album", out)
 	if err != nil {
-		fmt.Printf("Debug: リクエスト作成エラー: %v\n", err)
-		return "", err
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
-	fmt.Printf("Debug: 認証ヘッダー設定完了 (トークン長: %d文字)\n", len(c.accessToken))
+	c.debugf(out, "検索結果アルバム数: %d\n", len(searchResp.Albums.Items))
 
-	fmt.Printf("Debug: Spotify検索APIにリクエスト送信中...\n")
-	resp, err := c.httpClient.Do(req)
+	var candidates []provider.Candidate
+	for _, album := range searchResp.Albums.Items {
+		c.debugf(out, "見つかったアルバム: '%s'\n", album.Name)
+		c.debugf(out, "アルバムのアーティスト: %v\n", album.Artists)
+
+		candidate, ok := c.albumCandidate(album, out)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return nil, provider.ErrNotFound
+	}
+
+	return candidates, nil
+}
+
+// searchTracks は曲名とアーティスト名で曲を検索し、収録アルバムの画像を候補にする
+func (c *Client) searchTracks(ctx context.Context, query provider.Query, out io.Writer) ([]provider.Candidate, error) {
+	searchQuery := fmt.Sprintf("track:%s", query.Title)
+	if query.Artist != "" {
+		searchQuery += fmt.Sprintf(" artist:%s", query.Artist)
+	}
+
+	searchResp, err := c.search(ctx, searchQuery, "track", out)
 	if err != nil {
-		fmt.Printf("Debug: HTTPリクエストエラー: %v\n", err)
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	fmt.Printf("Debug: 検索レスポンスステータス: %d\n", resp.StatusCode)
+	c.debugf(out, "検索結果楽曲数: %d\n", len(searchResp.Tracks.Items))
 
-	// レスポンスボディを読み取り
-	body, err := io.ReadAll(resp.Body)
+	var candidates []provider.Candidate
+	for _, track := range searchResp.Tracks.Items {
+		c.debugf(out, "見つかった楽曲: '%s'\n", track.Name)
+		c.debugf(out, "楽曲のアーティスト: %v\n", track.Artists)
+
+		candidate, ok := c.albumCandidate(track.Album, out)
+		if !ok {
+			continue
+		}
+		candidate.Artist = joinArtists(track.Artists)
+		candidate.Title = track.Name
+		candidate.Duration = time.Duration(track.DurationMs) * time.Millisecond
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return nil, provider.ErrNotFound
+	}
+
+	return candidates, nil
+}
+
+// search はSpotify検索APIにリクエストを送信
+func (c *Client) search(ctx context.Context, searchQuery, searchType string, out io.Writer) (*SpotifySearchResponse, error) {
+	encodedQuery := url.QueryEscape(searchQuery)
+
+	c.debugf(out, "検索クエリ: '%s'\n", searchQuery)
+	c.debugf(out, "エンコード済みクエリ: '%s'\n", encodedQuery)
+
+	searchURL := fmt.Sprintf("%s/search?q=%s&type=%s&limit=%d", c.endpoints.APIBaseURL, encodedQuery, searchType, searchLimit)
+	c.debugf(out, "検索URL: %s\n", searchURL)
+
+	c.debugf(out, "Spotify検索APIにリクエスト送信中...\n")
+	status, body, err := c.get(ctx, searchURL, out)
 	if err != nil {
-		fmt.Printf("Debug: レスポンス読み取りエラー: %v\n", err)
-		return "", err
+		c.debugf(out, "HTTPリクエストエラー: %v\n", err)
+		return nil, err
 	}
 
-	fmt.Printf("Debug: 検索レスポンスボディ: %s\n", string(body))
+	c.debugf(out, "検索レスポンスステータス: %d\n", status)
+
+	c.debugf(out, "検索レスポンスボディ: %s\n", string(body))
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("Spotify検索に失敗: %d", status)
+	}
 
 	var searchResp SpotifySearchResponse
 	if err := json.Unmarshal(body, &searchResp); err != nil {
-		fmt.Printf("Debug: JSON解析エラー: %v\n", err)
-		return "", err
+		c.debugf(out, "JSON解析エラー: %v\n", err)
+		return nil, err
 	}
 
-	fmt.Printf("Debug: 検索結果楽曲数: %d\n", len(searchResp.Tracks.Items))
+	return &searchResp, nil
+}
 
-	if len(searchResp.Tracks.Items) == 0 {
-		fmt.Printf("Debug: 楽曲が見つかりませんでした\n")
-		return "", fmt.Errorf("アートワークが見つかりませんでした")
+// get は有効なアクセストークンを付けてGETリクエストを送信
+// 401の場合はトークンを再取得して1回だけ、429・5xxの場合は待機してから再試行する
+func (c *Client) get(ctx context.Context, requestURL string, out io.Writer) (int, []byte, error) {
+	token, err := c.validToken(ctx, out)
+	if err != nil {
+		return 0, nil, err
 	}
 
-	firstTrack := searchResp.Tracks.Items[0]
-	fmt.Printf("Debug: 見つかった楽曲: '%s'\n", firstTrack.Name)
-	fmt.Printf("Debug: 楽曲のアーティスト: %v\n", firstTrack.Artists)
-	fmt.Printf("Debug: アルバム名: '%s'\n", firstTrack.Album.Name)
-	fmt.Printf("Debug: 画像数: %d\n", len(firstTrack.Album.Images))
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return 0, nil, err
+		}
 
-	if len(firstTrack.Album.Images) == 0 {
-		fmt.Printf("Debug: アルバムに画像がありません\n")
-		return "", fmt.Errorf("アートワークが見つかりませんでした")
+		status, header, body, err := c.getWithToken(ctx, requestURL, token)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch {
+		case status == http.StatusUnauthorized && !refreshed:
+			c.debugf(out, "アクセストークンが無効なため再取得します\n")
+			refreshed = true
+			token, err = c.refreshToken(ctx, token)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+
+		case shouldRetry(status) && attempt < maxRetries:
+			delay, err := retryDelay(header, attempt)
+			if err != nil {
+				return status, body, err
+			}
+			fmt.Fprintf(out, "  Spotify APIがステータス %d を返しました。%v 待機して再試行します (%d/%d)\n", status, delay, attempt+1, maxRetries)
+			if err := sleep(ctx, delay); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		return status, body, nil
+	}
+}
+
+// getWithToken は指定したアクセストークンでGETリクエストを送信し、ステータス・ヘッダー・ボディを返す
+func (c *Client) getWithToken(ctx context.Context, requestURL, token string) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// albumCandidate はアルバムの最高解像度の画像から候補を作成
+func (c *Client) albumCandidate(album SpotifyAlbum, out io.Writer) (provider.Candidate, bool) {
+	c.debugf(out, "アルバム名: '%s'\n", album.Name)
+	c.debugf(out, "画像数: %d\n", len(album.Images))
+
+	if len(album.Images) == 0 {
+		c.debugf(out, "アルバムに画像がありません\n")
+		return provider.Candidate{}, false
 	}
 
 	// 最高解像度の画像を選択
-	bestImage := firstTrack.Album.Images[0]
-	for i, img := range firstTrack.Album.Images {
-		fmt.Printf("Debug: 画像%d - URL: %s, サイズ: %dx%d\n", i, img.URL, img.Width, img.Height)
+	bestImage := album.Images[0]
+	for i, img := range album.Images {
+		c.debugf(out, "画像%d - URL: %s, サイズ: %dx%d\n", i, img.URL, img.Width, img.Height)
 		if img.Height > bestImage.Height {
 			bestImage = img
 		}
 	}
 
-	fmt.Printf("Debug: 選択された画像: %s (%dx%d)\n", bestImage.URL, bestImage.Width, bestImage.Height)
+	c.debugf(out, "選択された画像: %s (%dx%d)\n", bestImage.URL, bestImage.Width, bestImage.Height)
+
+	return provider.Candidate{
+		Provider: ProviderName,
+		ImageURL: bestImage.URL,
+		Width:    bestImage.Width,
+		Height:   bestImage.Height,
+		Artist:   joinArtists(album.Artists),
+		Album:    album.Name,
+	}, true
+}
 
-	return bestImage.URL, nil
-}
\ No newline at end of file
+// joinArtists はアーティスト一覧を表示用の文字列に結合
+func joinArtists(artists []SpotifyArtist) string {
+	names := make([]string, 0, len(artists))
+	for _, a := range artists {
+		names = append(names, a.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,227 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"music-artwork-embedder/src/metadata"
+	"music-artwork-embedder/src/provider"
+)
+
+// albumResponse はバーコード検索で1件のアルバムが見つかった場合のレスポンス
+const albumResponse = `{"albums":{"items":[{"name":"A Night at the Opera","artists":[{"name":"Queen"}],"images":[` +
+	`{"url":"https://i.scdn.co/image/small","width":64,"height":64},` +
+	`{"url":"https://i.scdn.co/image/large","width":640,"height":640}]}]}}`
+
+// stubAPI はトークンAPIと検索APIを模したスタブサーバー
+type stubAPI struct {
+	t *testing.T
+
+	// search は検索リクエストの回数（1始まり）と受け取ったトークンからステータス・ヘッダー・ボディを返す
+	search func(n int, token string) (int, http.Header, string)
+
+	mu            sync.Mutex
+	tokenRequests int
+	queries       []string
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/token":
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "id" || secret != "secret" {
+			s.t.Errorf("token request auth = %q, %q, %v", id, secret, ok)
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			s.t.Errorf("token request grant_type = %q (%v)", r.PostForm.Get("grant_type"), err)
+		}
+		s.tokenRequests++
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600}`, s.tokenRequests)
+
+	case "/v1/search":
+		s.queries = append(s.queries, r.URL.Query().Get("q"))
+		status, header, body := s.search(len(s.queries), strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+
+	default:
+		s.t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+	}
+}
+
+// redirectTransport は全てのリクエストをスタブサーバーへ転送する
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newStubClient はスタブサーバーを起動し、デフォルトのURLのままトランスポート経由でそこへ接続するクライアントを返す
+func newStubClient(t *testing.T, api *stubAPI) *Client {
+	t.Helper()
+	api.t = t
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient("id", "secret", Endpoints{}, 0, redirectTransport{target: target}, false)
+}
+
+func barcodeQuery() provider.Query {
+	return provider.Query{TrackMetadata: metadata.TrackMetadata{
+		Artist:  "Queen",
+		Album:   "A Night at the Opera",
+		Title:   "Bohemian Rhapsody",
+		Barcode: "00602547202734",
+	}}
+}
+
+func TestClientSearch(t *testing.T) {
+	tests := []struct {
+		name          string
+		search        func(n int, token string) (int, http.Header, string)
+		wantQueries   []string
+		wantTokens    int // トークンAPIへのリクエスト数（初期化を含む）
+		wantErr       bool
+		wantNotFound  bool
+		wantCandidate string
+	}{
+		{
+			name: "バーコードで見つかった候補を確実な一致として返す",
+			search: func(n int, token string) (int, http.Header, string) {
+				if token != "token-1" {
+					return http.StatusUnauthorized, nil, `{}`
+				}
+				return http.StatusOK, nil, albumResponse
+			},
+			wantQueries:   []string{"upc:00602547202734"},
+			wantTokens:    1,
+			wantCandidate: "https://i.scdn.co/image/large",
+		},
+		{
+			name: "401の場合はトークンを再取得して再試行",
+			search: func(n int, token string) (int, http.Header, string) {
+				if token != "token-2" {
+					return http.StatusUnauthorized, nil, `{"error":{"status":401,"message":"The access token expired"}}`
+				}
+				return http.StatusOK, nil, albumResponse
+			},
+			wantQueries:   []string{"upc:00602547202734", "upc:00602547202734"},
+			wantTokens:    2,
+			wantCandidate: "https://i.scdn.co/image/large",
+		},
+		{
+			name: "再取得したトークンも拒否された場合は再試行しない",
+			search: func(n int, token string) (int, http.Header, string) {
+				return http.StatusUnauthorized, nil, `{}`
+			},
+			wantQueries: []string{"upc:00602547202734", "upc:00602547202734"},
+			wantTokens:  2,
+			wantErr:     true,
+		},
+		{
+			name: "429の場合はRetry-Afterだけ待機して再試行",
+			search: func(n int, token string) (int, http.Header, string) {
+				if n == 1 {
+					return http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}}, `{}`
+				}
+				return http.StatusOK, nil, albumResponse
+			},
+			wantQueries:   []string{"upc:00602547202734", "upc:00602547202734"},
+			wantTokens:    1,
+			wantCandidate: "https://i.scdn.co/image/large",
+		},
+		{
+			name: "Retry-Afterが長すぎる場合は再試行しない",
+			search: func(n int, token string) (int, http.Header, string) {
+				return http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}}, `{}`
+			},
+			wantQueries: []string{"upc:00602547202734"},
+			wantTokens:  1,
+			wantErr:     true,
+		},
+		{
+			name: "識別子で見つからない場合はテキスト検索を行わない",
+			search: func(n int, token string) (int, http.Header, string) {
+				return http.StatusOK, nil, `{"albums":{"items":[]},"tracks":{"items":[]}}`
+			},
+			wantQueries:  []string{"upc:00602547202734"},
+			wantTokens:   1,
+			wantErr:      true,
+			wantNotFound: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &stubAPI{search: tt.search}
+			client := newStubClient(t, api)
+
+			ctx := context.Background()
+			if err := client.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize() error = %v", err)
+			}
+			candidates, err := client.Search(ctx, barcodeQuery(), io.Discard)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Search() returned %d candidates, want error", len(candidates))
+				}
+				if got := errors.Is(err, provider.ErrNotFound); got != tt.wantNotFound {
+					t.Errorf("errors.Is(%v, ErrNotFound) = %v, want %v", err, got, tt.wantNotFound)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Search() error = %v", err)
+				}
+				if len(candidates) != 1 || candidates[0].ImageURL != tt.wantCandidate || !candidates[0].Exact {
+					t.Errorf("Search() = %+v, want one exact candidate with %s", candidates, tt.wantCandidate)
+				}
+			}
+
+			if strings.Join(api.queries, "\n") != strings.Join(tt.wantQueries, "\n") {
+				t.Errorf("search queries = %q, want %q", api.queries, tt.wantQueries)
+			}
+			if api.tokenRequests != tt.wantTokens {
+				t.Errorf("token requests = %d, want %d", api.tokenRequests, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestInitializeRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":"invalid_client","error_description":"Invalid client"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("id", "wrong", Endpoints{TokenURL: server.URL + "/api/token", APIBaseURL: server.URL + "/v1"}, 0, nil, false)
+	err := client.Initialize(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("Initialize() error = %v, want invalid_client", err)
+	}
+}
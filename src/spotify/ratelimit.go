@@ -0,0 +1,92 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRetries は429・5xxの場合に再試行する最大回数
+	maxRetries = 5
+	// initialBackoff は指数バックオフの初期待機時間
+	initialBackoff = time.Second
+	// maxBackoff は指数バックオフの待機時間の上限
+	maxBackoff = 30 * time.Second
+	// maxRetryAfter はRetry-Afterに従って待機する上限（これを超える場合は再試行しない）
+	maxRetryAfter = 5 * time.Minute
+)
+
+// rateLimiter はリクエストの送信間隔を一定以上に保つクライアント側のレート制限
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter は1秒あたりのリクエスト数の上限を指定してレート制限を作成（0以下は無制限）
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+}
+
+// Wait は次のリクエストを送信できるまで待機（ctxがキャンセルされた場合はそのエラーを返す）
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	l.next = now.Add(wait + l.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// sleep は指定時間待機する（ctxがキャンセルされた場合はそのエラーを返す）
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// shouldRetry は再試行すべきレスポンスステータスか（429・5xx）を判定
+func shouldRetry(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryDelay は再試行までの待機時間を返す
+// Retry-Afterヘッダーがあればそれに従い、なければ上限付きの指数バックオフ
+func retryDelay(header http.Header, attempt int) (time.Duration, error) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			delay := time.Duration(seconds) * time.Second
+			if delay > maxRetryAfter {
+				return 0, fmt.Errorf("Retry-Afterの待機時間が長すぎます: %v", delay)
+			}
+			return delay, nil
+		}
+	}
+
+	delay := initialBackoff << attempt
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	return delay, nil
+}
@@ -0,0 +1,109 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// tokenRefreshMargin は有効期限のこの時間前にトークンを再取得する
+const tokenRefreshMargin = 60 * time.Second
+
+// tokenResponse はトークンAPIのレスポンス構造体
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"` // 有効期間（秒）
+
+	// 認証失敗時に返される
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// GetToken はSpotify Web APIのアクセストークンを取得
+func (c *Client) GetToken(ctx context.Context, clientID, clientSecret string) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.clientID = clientID
+	c.clientSecret = clientSecret
+	return c.fetchToken(ctx)
+}
+
+// validToken は有効なアクセストークンを返す（期限切れ間近なら再取得）
+func (c *Client) validToken(ctx context.Context, out io.Writer) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.accessToken == "" || time.Now().Add(tokenRefreshMargin).After(c.tokenExpiry) {
+		c.debugf(out, "アクセストークンの有効期限が近いため再取得します\n")
+		if err := c.fetchToken(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.accessToken, nil
+}
+
+// refreshToken はAPIに拒否されたトークンを再取得する
+// 他のリクエストが既に再取得済みの場合はそのトークンを返す
+func (c *Client) refreshToken(ctx context.Context, rejected string) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.accessToken != rejected {
+		return c.accessToken, nil
+	}
+	if err := c.fetchToken(ctx); err != nil {
+		return "", err
+	}
+	return c.accessToken, nil
+}
+
+// fetchToken はトークンAPIからアクセストークンを取得（tokenMuを保持した状態で呼ぶ）
+func (c *Client) fetchToken(ctx context.Context) error {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoints.TokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var tokenResp tokenResponse
+	jsonErr := json.Unmarshal(body, &tokenResp)
+
+	if resp.StatusCode != http.StatusOK {
+		if tokenResp.Error != "" {
+			return fmt.Errorf("トークン取得が拒否されました: %d %s (%s)", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+		}
+		return fmt.Errorf("トークン取得が拒否されました: %d", resp.StatusCode)
+	}
+	if jsonErr != nil {
+		return fmt.Errorf("トークンレスポンスの解析に失敗: %w", jsonErr)
+	}
+	if tokenResp.AccessToken == "" {
+		return fmt.Errorf("トークンレスポンスにアクセストークンが含まれていません")
+	}
+
+	c.accessToken = tokenResp.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return nil
+}
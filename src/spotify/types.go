@@ -1,21 +1,35 @@
 package spotify
 
+// SpotifyImage はアルバム画像
+type SpotifyImage struct {
+	URL    string `json:"url"`
+	Height int    `json:"height"`
+	Width  int    `json:"width"`
+}
+
+// SpotifyArtist はアーティスト情報
+type SpotifyArtist struct {
+	Name string `json:"name"`
+}
+
+// SpotifyAlbum はアルバム情報
+type SpotifyAlbum struct {
+	Name    string          `json:"name"`
+	Images  []SpotifyImage  `json:"images"`
+	Artists []SpotifyArtist `json:"artists"`
+}
+
 // SpotifySearchResponse はSpotify検索APIのレスポンス構造体
 type SpotifySearchResponse struct {
 	Tracks struct {
 		Items []struct {
-			Name  string `json:"name"`
-			Album struct {
-				Name   string `json:"name"`
-				Images []struct {
-					URL    string `json:"url"`
-					Height int    `json:"height"`
-					Width  int    `json:"width"`
-				} `json:"images"`
-			} `json:"album"`
-			Artists []struct {
-				Name string `json:"name"`
-			} `json:"artists"`
+			Name       string          `json:"name"`
+			DurationMs int             `json:"duration_ms"`
+			Album      SpotifyAlbum    `json:"album"`
+			Artists    []SpotifyArtist `json:"artists"`
 		} `json:"items"`
 	} `json:"tracks"`
+	Albums struct {
+		Items []SpotifyAlbum `json:"items"`
+	} `json:"albums"`
 }